@@ -0,0 +1,616 @@
+package bot
+
+import (
+	"Discord_bot_v1/llm_utils"
+	todo_utils "Discord_bot_v1/todo-utils"
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// agentTTL is how long the assistant remembers a conversation without a new message
+	agentTTL = 30 * time.Minute
+	// maxAgentSteps is how many times the assistant may call tools before it has to answer
+	maxAgentSteps = 8
+	// maxAgentHistory is how many turns of a conversation are sent back to the model
+	maxAgentHistory = 40
+	// agentListLimit is how many tasks list_tasks hands to the model at most
+	agentListLimit = 50
+)
+
+// AgentSession is a user's conversation with the assistant
+type AgentSession struct {
+	// History holds the turns so far, oldest first
+	History []llm_utils.Message
+	// Pending are destructive calls of the model's last turn, waiting for the user to confirm
+	Pending []llm_utils.ToolCall
+	// Responses are the results of the other calls of that turn
+	Responses []llm_utils.ToolResult
+	// PendingID ties the buttons of a confirmation card to the Pending calls it shows
+	PendingID string
+	// CardChannelID and CardMessageID locate that card, so its buttons can be removed
+	CardChannelID string
+	CardMessageID string
+}
+
+// userAgents stores every user's conversation with the assistant
+var userAgents StateStore[AgentSession]
+
+// agentTool is a function the assistant can call, backed by TodoApp
+type agentTool struct {
+	llm_utils.ToolDef
+	// Destructive calls only run after the user presses Confirm
+	Destructive bool
+	Run         func(ctx context.Context, userID string, args map[string]interface{}) map[string]interface{}
+}
+
+// agentTools are offered to the assistant in this order
+var agentTools = []agentTool{
+	{
+		ToolDef: llm_utils.ToolDef{
+			Name:        "list_tasks",
+			Description: "List the user's tasks, optionally filtered. Use it to find the IDs of tasks before changing them.",
+			Parameters: &llm_utils.Schema{
+				Type: "object",
+				Properties: map[string]*llm_utils.Schema{
+					"status":   {Type: "string", Enum: taskStatuses},
+					"priority": {Type: "string", Enum: todo_utils.Priorities},
+					"tag":      {Type: "string", Description: "only tasks with this tag, without the #"},
+					"search":   {Type: "string", Description: "words to look for in titles, tags and descriptions; typos and Indonesian/English synonyms are fine"},
+				},
+			},
+		},
+		Run: agentListTasks,
+	},
+	{
+		ToolDef: llm_utils.ToolDef{
+			Name:        "create_task",
+			Description: "Create a new task.",
+			Parameters: &llm_utils.Schema{
+				Type: "object",
+				Properties: map[string]*llm_utils.Schema{
+					"title":       {Type: "string"},
+					"description": {Type: "string"},
+					"status":      {Type: "string", Enum: taskStatuses},
+					"priority":    {Type: "string", Enum: todo_utils.Priorities},
+					"due":         {Type: "string", Description: "due date in the user's timezone, e.g. 2026-10-20 or 2026-10-20 15:00"},
+					"tags":        {Type: "array", Items: &llm_utils.Schema{Type: "string"}},
+				},
+				Required: []string{"title"},
+			},
+		},
+		Run: agentCreateTask,
+	},
+	{
+		ToolDef: llm_utils.ToolDef{
+			Name:        "update_task",
+			Description: "Change a task. Only the given fields change; tags, if given, replace the current ones.",
+			Parameters: &llm_utils.Schema{
+				Type: "object",
+				Properties: map[string]*llm_utils.Schema{
+					"id":          {Type: "string", Description: "the task's id or handle, e.g. T-3"},
+					"title":       {Type: "string"},
+					"description": {Type: "string"},
+					"status":      {Type: "string", Enum: taskStatuses},
+					"priority":    {Type: "string", Enum: todo_utils.Priorities},
+					"due":         {Type: "string", Description: "due date in the user's timezone, e.g. 2026-10-20 or 2026-10-20 15:00, or \"none\" to remove it"},
+					"tags":        {Type: "array", Items: &llm_utils.Schema{Type: "string"}},
+				},
+				Required: []string{"id"},
+			},
+		},
+		Run: agentUpdateTask,
+	},
+	{
+		ToolDef: llm_utils.ToolDef{
+			Name:        "delete_task",
+			Description: "Delete a task. The user is asked to confirm first.",
+			Parameters: &llm_utils.Schema{
+				Type: "object",
+				Properties: map[string]*llm_utils.Schema{
+					"id": {Type: "string", Description: "the task's id or handle, e.g. T-3"},
+				},
+				Required: []string{"id"},
+			},
+		},
+		Destructive: true,
+		Run:         agentDeleteTask,
+	},
+}
+
+// agentToolset declares agentTools to the model
+var agentToolset = func() []llm_utils.ToolDef {
+	definitions := make([]llm_utils.ToolDef, len(agentTools))
+	for i, tool := range agentTools {
+		definitions[i] = tool.ToolDef
+	}
+	return definitions
+}()
+
+// findAgentTool returns the tool the model called
+func findAgentTool(name string) (agentTool, bool) {
+	for _, tool := range agentTools {
+		if tool.Name == name {
+			return tool, true
+		}
+	}
+	return agentTool{}, false
+}
+
+func init() {
+	commandRouter.Register(&Command{
+		Name:        "ask",
+		Usage:       "<anything about your tasks>",
+		Description: "Ask the assistant to find or change tasks, e.g. !ask move everything about the report to done. You can also mention me",
+		Category:    "Task Management",
+		Handler:     askCommand,
+	})
+}
+
+// askCommand passes the message on to the assistant, which answers in DMs
+func askCommand(ctx *CommandContext) {
+	if ctx.RawArgs == "" {
+		ctx.Reply(fmt.Sprintf("Ask me something about your tasks, e.g. `%sask what's due this week?`", commandRouter.Prefix))
+		return
+	}
+	if llmService == nil {
+		ctx.Reply("🤖 The assistant isn't available right now.")
+		return
+	}
+	ctx.NotifyDM("for my answer")
+
+	userID := ctx.Message.Author.ID
+	session, pending := takeAgentPending(userID, "")
+	if pending {
+		// The model is still waiting for the unconfirmed calls, tell it they didn't happen
+		session.History = append(session.History, llm_utils.Message{Role: llm_utils.RoleUser, ToolResults: append(session.Responses, declineAgentCalls(session.Pending, "the user moved on without confirming")...)})
+		closeAgentCard(ctx.Session, &session, "🛑 Skipped, you asked something else. Nothing was deleted.")
+		session.Pending, session.Responses = nil, nil
+	}
+	session.History = append(session.History, llm_utils.Message{Role: llm_utils.RoleUser, Text: ctx.RawArgs})
+
+	runAgent(ctx.Session, userID, ctx.DM(), &session)
+}
+
+// mentionedBot reports whether the message mentions the bot, and returns it without the mention
+func mentionedBot(s *discordgo.Session, m *discordgo.MessageCreate) (string, bool) {
+	botID := s.State.User.ID
+	for _, user := range m.Mentions {
+		if user.ID == botID {
+			text := strings.NewReplacer("<@"+botID+">", "", "<@!"+botID+">", "").Replace(m.Content)
+			return strings.TrimSpace(text), true
+		}
+	}
+	return "", false
+}
+
+// agentSystemPrompt tells the model who it is and what it may do
+func agentSystemPrompt(userID string) string {
+	now := time.Now().In(userLocation(userID))
+	return "You are WinayaBot, a friendly assistant in Discord that manages the user's to-do list with the tools you have. " +
+		fmt.Sprintf("Now is %s (%s) in the user's timezone. ", now.Format("2006-01-02 15:04"), now.Format("Monday")) +
+		"Always look tasks up with list_tasks before changing them and never make up IDs. " +
+		"When a request covers several tasks, change each of them. Deleting needs the user's confirmation, which the bot asks for. " +
+		"Refer to tasks by their title and handle, e.g. T-3. Keep answers short and use Discord markdown. " +
+		"Reply in the language the user writes in."
+}
+
+// runAgent lets the model take turns, running the tools it calls, until it answers in text.
+// Destructive calls pause the loop until the user confirms them with a button.
+func runAgent(s *discordgo.Session, userID string, channelID string, session *AgentSession) {
+	for step := 0; step < maxAgentSteps; step++ {
+		s.ChannelTyping(channelID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), llmTimeout)
+		turn, err := llmService.Chat(ctx, agentSystemPrompt(userID), session.History, agentToolset)
+		cancel()
+		if err != nil {
+			log.Printf("Error from assistant for user %s: %v", userID, err)
+			s.ChannelMessageSend(channelID, "🤖 Sorry, something went wrong on my side. Please try again.")
+			// The history may end halfway through a tool loop, start over next time
+			userAgents.Delete(userID)
+			return
+		}
+		session.History = append(session.History, turn)
+
+		calls := turn.ToolCalls
+		if len(calls) == 0 {
+			reply := strings.TrimSpace(turn.Text)
+			if reply == "" {
+				reply = "🤖 Done."
+			}
+			s.ChannelMessageSend(channelID, truncate(reply, 2000))
+			saveAgentSession(userID, session)
+			return
+		}
+
+		var responses []llm_utils.ToolResult
+		var pending []llm_utils.ToolCall
+		for _, call := range calls {
+			if tool, ok := findAgentTool(call.Name); ok && tool.Destructive {
+				pending = append(pending, call)
+				continue
+			}
+			responses = append(responses, runAgentTool(userID, call))
+		}
+
+		if len(pending) > 0 {
+			session.Pending, session.Responses = pending, responses
+			session.PendingID = strconv.FormatInt(time.Now().UnixNano(), 36)
+			session.CardChannelID, session.CardMessageID = "", ""
+			saveAgentSession(userID, session)
+			askAgentConfirmation(s, userID, channelID, session.PendingID, pending)
+			return
+		}
+		session.History = append(session.History, llm_utils.Message{Role: llm_utils.RoleUser, ToolResults: responses})
+	}
+
+	s.ChannelMessageSend(channelID, "🤖 That took more steps than I'm allowed. Try asking for less at once.")
+	userAgents.Delete(userID)
+}
+
+// agentMu serialises taking the pending calls of a session
+var agentMu sync.Mutex
+
+// takeAgentPending returns the user's session and reports whether it has calls waiting
+// for confirmation, the ones with pendingID unless it is empty. Those are cleared in the
+// store before it returns, so a second Confirm press or a new !ask can't run or decline
+// them again, and an old card's buttons can't act on newer calls.
+func takeAgentPending(userID string, pendingID string) (AgentSession, bool) {
+	agentMu.Lock()
+	defer agentMu.Unlock()
+
+	session, _ := userAgents.Get(userID)
+	if len(session.Pending) == 0 || (pendingID != "" && session.PendingID != pendingID) {
+		return session, false
+	}
+	cleared := session
+	cleared.Pending, cleared.Responses = nil, nil
+	cleared.PendingID, cleared.CardChannelID, cleared.CardMessageID = "", "", ""
+	userAgents.Set(userID, cleared, agentTTL)
+	return session, true
+}
+
+// setAgentCard remembers where the confirmation card for pendingID was sent,
+// unless those calls have been taken in the meantime
+func setAgentCard(userID string, pendingID string, channelID string, messageID string) {
+	agentMu.Lock()
+	defer agentMu.Unlock()
+
+	session, exists := userAgents.Get(userID)
+	if !exists || session.PendingID != pendingID {
+		return
+	}
+	session.CardChannelID, session.CardMessageID = channelID, messageID
+	userAgents.Set(userID, session, agentTTL)
+}
+
+// closeAgentCard removes the buttons of a session's confirmation card and adds the outcome
+func closeAgentCard(s *discordgo.Session, session *AgentSession, outcome string) {
+	if session.CardMessageID == "" {
+		return
+	}
+	message, err := s.ChannelMessage(session.CardChannelID, session.CardMessageID)
+	content := outcome
+	if err == nil {
+		content = message.Content + "\n" + outcome
+	}
+	_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         session.CardMessageID,
+		Channel:    session.CardChannelID,
+		Content:    &content,
+		Components: &[]discordgo.MessageComponent{},
+	})
+	if err != nil {
+		log.Printf("Failed to close assistant confirmation: %v", err)
+	}
+}
+
+// saveAgentSession stores the session, dropping its oldest turns past maxAgentHistory.
+// Turns are dropped up to a user message, so no tool result is left without its call.
+func saveAgentSession(userID string, session *AgentSession) {
+	history := session.History
+	if len(history) > maxAgentHistory {
+		history = history[len(history)-maxAgentHistory:]
+		for len(history) > 0 && (history[0].Role != llm_utils.RoleUser || len(history[0].ToolResults) > 0) {
+			history = history[1:]
+		}
+	}
+	session.History = history
+	userAgents.Set(userID, *session, agentTTL)
+}
+
+// runAgentTool runs one call of the model and returns its result
+func runAgentTool(userID string, call llm_utils.ToolCall) llm_utils.ToolResult {
+	result := map[string]interface{}{"error": "there is no function called " + call.Name}
+	if tool, ok := findAgentTool(call.Name); ok {
+		ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+		result = tool.Run(ctx, userID, call.Args)
+		cancel()
+	}
+	return llm_utils.ToolResult{CallID: call.ID, Name: call.Name, Response: result}
+}
+
+// declineAgentCalls answers calls that were not run, with the reason why
+func declineAgentCalls(calls []llm_utils.ToolCall, reason string) []llm_utils.ToolResult {
+	results := make([]llm_utils.ToolResult, len(calls))
+	for i, call := range calls {
+		results[i] = llm_utils.ToolResult{CallID: call.ID, Name: call.Name, Response: map[string]interface{}{"error": reason}}
+	}
+	return results
+}
+
+// askAgentConfirmation lists what the destructive calls would do, with Confirm and Cancel buttons.
+// Custom IDs are agent_confirm_<pendingID> and agent_cancel_<pendingID>.
+func askAgentConfirmation(s *discordgo.Session, userID string, channelID string, pendingID string, pending []llm_utils.ToolCall) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	lines := []string{fmt.Sprintf("🗑️ **I'm about to delete %d %s:**", len(pending), pluralTasks(len(pending)))}
+	for _, call := range pending {
+		taskID := agentTaskID(userID, agentArg(call.Args, "id"))
+		if task, err := TodoApp.GetTask(ctx, taskID, userID); err == nil {
+			lines = append(lines, fmt.Sprintf("• %s %s", handleLabel(userID, task), truncate(task.Title, 80)))
+		} else {
+			lines = append(lines, fmt.Sprintf("• `%s` (I couldn't look it up)", taskID))
+		}
+	}
+
+	message, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content: truncate(strings.Join(lines, "\n"), 2000),
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "🗑️ Delete", Style: discordgo.DangerButton, CustomID: "agent_confirm_" + pendingID},
+				discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: "agent_cancel_" + pendingID},
+			}},
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to send assistant confirmation: %v", err)
+		return
+	}
+	setAgentCard(userID, pendingID, message.ChannelID, message.ID)
+}
+
+// handleAgentComponent runs or declines the calls waiting for confirmation, then lets the assistant carry on
+func handleAgentComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID := interactionUserID(i)
+	if userID == "" {
+		return
+	}
+
+	parts := strings.SplitN(i.MessageComponentData().CustomID, "_", 3)
+	if len(parts) != 3 {
+		return
+	}
+	session, pending := takeAgentPending(userID, parts[2])
+	if !pending {
+		// An old card, its calls were declined or replaced since
+		content := "⌛ This is no longer waiting for confirmation. Just ask me again."
+		if i.Message != nil {
+			content = i.Message.Content + "\n" + content
+		}
+		err := updateMessage(s, i, &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: []discordgo.MessageComponent{},
+		})
+		if err != nil {
+			log.Printf("Failed to update assistant confirmation: %v", err)
+		}
+		return
+	}
+
+	confirmed := parts[1] == "confirm"
+	outcome := "🛑 Cancelled, nothing was deleted."
+	if confirmed {
+		outcome = "✅ Confirmed."
+	}
+	content := outcome
+	if i.Message != nil {
+		content = i.Message.Content + "\n" + outcome
+	}
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to update assistant confirmation: %v", err)
+	}
+
+	responses := session.Responses
+	if confirmed {
+		for _, call := range session.Pending {
+			responses = append(responses, runAgentTool(userID, call))
+		}
+	} else {
+		responses = append(responses, declineAgentCalls(session.Pending, "the user cancelled, nothing was deleted")...)
+	}
+	session.History = append(session.History, llm_utils.Message{Role: llm_utils.RoleUser, ToolResults: responses})
+	session.Pending, session.Responses = nil, nil
+
+	runAgent(s, userID, dmChannelID(s, userID, i.ChannelID), &session)
+}
+
+// --- Tools ---
+
+// agentArg reads a string argument, empty if it is missing
+func agentArg(args map[string]interface{}, name string) string {
+	value, _ := args[name].(string)
+	return strings.TrimSpace(value)
+}
+
+// agentTags reads the tags argument, reporting false if it is missing
+func agentTags(args map[string]interface{}) ([]string, bool) {
+	values, ok := args["tags"].([]interface{})
+	if !ok {
+		return nil, false
+	}
+	tags := []string{}
+	for _, value := range values {
+		if raw, ok := value.(string); ok {
+			if tag, ok := todo_utils.NormalizeTag(raw); ok {
+				tags = todo_utils.MergeTags(tags, []string{tag})
+			}
+		}
+	}
+	return tags, true
+}
+
+// agentTaskID resolves a handle like T-3 to its task ID, anything else is taken as an ID
+func agentTaskID(userID string, ref string) string {
+	if handle, ok := parseHandle(ref); ok {
+		if taskID, ok := lookupHandle(userID, handle); ok {
+			return taskID
+		}
+	}
+	return ref
+}
+
+// agentError reports a failed call to the model
+func agentError(err error) map[string]interface{} {
+	return map[string]interface{}{"error": strings.TrimPrefix(todoErrorMessage(err), "❌ ")}
+}
+
+// agentTask is how a task is shown to the model
+func agentTask(userID string, task *todo_utils.Task) map[string]interface{} {
+	result := map[string]interface{}{
+		"id":       task.ID,
+		"handle":   taskHandle(userID, task),
+		"title":    task.Title,
+		"status":   task.Status,
+		"priority": task.EffectivePriority(),
+		"tags":     task.Tags,
+	}
+	if task.Description != "" {
+		result["description"] = task.Description
+	}
+	if due, ok := task.Due(); ok {
+		result["due"] = due.In(userLocation(userID)).Format("2006-01-02 15:04")
+	}
+	return result
+}
+
+// agentInput applies the arguments of create_task or update_task to input
+func agentInput(userID string, input *todo_utils.TaskInput, args map[string]interface{}) error {
+	if title := agentArg(args, "title"); title != "" {
+		input.Title = title
+	}
+	if description := agentArg(args, "description"); description != "" {
+		input.Description = description
+	}
+	if value := agentArg(args, "status"); value != "" {
+		status, err := validateStatus(value)
+		if err != nil {
+			return err
+		}
+		input.Status = status
+	}
+	if value := agentArg(args, "priority"); value != "" {
+		priority, err := validatePriority(value)
+		if err != nil {
+			return err
+		}
+		input.Priority = priority
+	}
+	if value := agentArg(args, "due"); value != "" {
+		due, err := parseDueDate(userID, value)
+		if err != nil {
+			return err
+		}
+		if due == dueDateNone {
+			due = ""
+		}
+		input.DueDate = due
+	}
+	if tags, ok := agentTags(args); ok {
+		input.Tags = tags
+	}
+	return nil
+}
+
+// agentListTasks implements list_tasks
+func agentListTasks(ctx context.Context, userID string, args map[string]interface{}) map[string]interface{} {
+	tasks, err := fetchAllTasks(ctx, userID)
+	if err != nil {
+		return agentError(err)
+	}
+
+	query := todo_utils.TaskQuery{Status: agentArg(args, "status"), Priority: agentArg(args, "priority"), Tag: strings.TrimPrefix(agentArg(args, "tag"), "#")}
+	var matching []todo_utils.Task
+	for _, task := range tasks {
+		if query.Matches(&task) {
+			matching = append(matching, task)
+		}
+	}
+	if search := agentArg(args, "search"); search != "" {
+		matching = searchTasks(matching, search)
+	} else {
+		sortByDueDate(matching)
+	}
+
+	listed := []map[string]interface{}{}
+	for n, task := range matching {
+		if n == agentListLimit {
+			break
+		}
+		listed = append(listed, agentTask(userID, &task))
+	}
+	return map[string]interface{}{"tasks": listed, "total": len(matching)}
+}
+
+// agentCreateTask implements create_task
+func agentCreateTask(ctx context.Context, userID string, args map[string]interface{}) map[string]interface{} {
+	input := todo_utils.TaskInput{Status: taskStatuses[0], Priority: todo_utils.DefaultPriority}
+	if err := agentInput(userID, &input, args); err != nil {
+		return agentError(err)
+	}
+	if input.Title == "" {
+		return map[string]interface{}{"error": "a title is required"}
+	}
+
+	task, err := TodoApp.CreateTask(ctx, input, userID)
+	if err != nil {
+		return agentError(err)
+	}
+	return map[string]interface{}{"created": agentTask(userID, task)}
+}
+
+// agentUpdateTask implements update_task. The change can be undone with !todo-undo.
+func agentUpdateTask(ctx context.Context, userID string, args map[string]interface{}) map[string]interface{} {
+	task, err := TodoApp.GetTask(ctx, agentTaskID(userID, agentArg(args, "id")), userID)
+	if err != nil {
+		return agentError(err)
+	}
+	input := task.Input()
+	if err := agentInput(userID, &input, args); err != nil {
+		return agentError(err)
+	}
+
+	updated, _, err := updateTaskWithUndo(ctx, userID, task, input)
+	if err != nil {
+		return agentError(err)
+	}
+	return map[string]interface{}{"updated": agentTask(userID, updated)}
+}
+
+// agentDeleteTask implements delete_task, once the user has confirmed it
+func agentDeleteTask(ctx context.Context, userID string, args map[string]interface{}) map[string]interface{} {
+	task, err := TodoApp.GetTask(ctx, agentTaskID(userID, agentArg(args, "id")), userID)
+	if err != nil {
+		return agentError(err)
+	}
+	if _, err := deleteTaskWithUndo(ctx, userID, task); err != nil {
+		return agentError(err)
+	}
+	return map[string]interface{}{"deleted": task.Title}
+}
@@ -1,17 +1,18 @@
 package bot
 
 import (
+	"Discord_bot_v1/config"
 	"Discord_bot_v1/llm_utils"
 	todo_utils "Discord_bot_v1/todo-utils"
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
-	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 )
@@ -19,46 +20,110 @@ import (
 var llmService *llm_utils.LLMService
 var TodoApp *todo_utils.TodoApp
 
-// ConversationState keeps track of where the user is in the flow
+// commandTimeout bounds the backend calls made for a single command or wizard reply.
+// Set from the config on Start.
+var commandTimeout = 15 * time.Second
+
+// llmTimeout bounds a single LLM call, which can take far longer than a backend call
+const llmTimeout = 60 * time.Second
+
+// interactionTimeout keeps backend calls inside Discord's 3 second window to answer an interaction
+const interactionTimeout = 2500 * time.Millisecond
+
+// debugLogging is true when the configured log level is "debug"
+var debugLogging bool
+
+// discordLogLevels maps config log levels to discordgo's
+var discordLogLevels = map[string]int{
+	"debug": discordgo.LogDebug,
+	"info":  discordgo.LogInformational,
+	"warn":  discordgo.LogWarning,
+	"error": discordgo.LogError,
+}
+
+// ConversationState keeps track of where the user is in a wizard
 type ConversationState struct {
-	Step       int
-	TaskTitle  string
-	TaskID     string
-	TaskStatus string
-	Action     string // "create", "update", or "delete"
-	Attempts   int    // Number of attempts for update/delete operations
-	TaskNumber int    // The friendly number the user provided
+	Action   string            // Name of the wizard, e.g. "create", "update", or "delete"
+	Step     int               // Index of the current wizard step
+	Attempts int               // Number of invalid answers to the current step
+	Data     map[string]string // Answers so far, keyed by step name
 }
 
 // PaginationState keeps track of the current page for each user
 type PaginationState struct {
 	Page int
+	// Query is the filter and sort order the list was shown with
+	Query todo_utils.TaskQuery
 	// TaskIDMap maps friendly numbers to actual task IDs
 	TaskIDMap map[int]string
 }
 
-// userStates stores ongoing conversations per user
-var userStates = make(map[string]*ConversationState)
-
-// userPagination stores pagination state for todo lists per user
-var userPagination = make(map[string]*PaginationState)
-
 // Start initializes and runs the Discord bot.
-func Start(token string, service llm_utils.LLMService) {
+// Conversation and pagination state is persisted under cfg.DataDir; an empty DataDir keeps it in memory only.
+// provider runs every LLM feature; nil, with llm.provider set to none, turns them off.
+func Start(cfg *config.AppConfig, provider llm_utils.LLMProvider) {
 	// 1. CREATE DISCORD SESSION
-	dg, err := discordgo.New("Bot " + token)
+	dg, err := discordgo.New("Bot " + cfg.Token)
 	if err != nil {
 		log.Fatalf("Error creating Discord session: %v", err)
 	}
+	dg.LogLevel = discordLogLevels[cfg.LogLevel]
+	debugLogging = cfg.LogLevel == "debug"
+
+	// apply the remaining settings
+	commandRouter.Prefix = cfg.CommandPrefix
+	taskListPageSize = cfg.PageSize
+	commandTimeout = cfg.Timeouts.Command
+	if loc, err := time.LoadLocation(cfg.Timezone); err == nil {
+		defaultLocation = loc
+	}
 
 	// setting llm service to facilitate llm operations
-	llmService = &service
+	if provider != nil {
+		llmService = llm_utils.NewLLMService(provider)
+	}
 
 	// initialize todoapp
 	client := &http.Client{}
 
 	// Initialize your TodoApp instance
-	TodoApp = todo_utils.InitTodoAPP(client, "http://backend:8080/api")
+	TodoApp = todo_utils.InitTodoAPP(client, cfg.BackendURL)
+	TodoApp.RequestTimeout = cfg.Timeouts.Backend
+
+	// Set up per-user state stores. Their sweepers run until the bot shuts down.
+	stop := make(chan struct{})
+	defer close(stop)
+	var reminderStore ReminderStore = NewMemoryReminderStore()
+	if cfg.DataDir == "" {
+		initStateStores(dg, stop)
+	} else {
+		db, err := openStateDB(cfg.DataDir)
+		if err != nil {
+			log.Fatalf("Error opening state database: %v", err)
+		}
+		defer db.Close()
+
+		if err := initPersistentStateStores(dg, db, stop); err != nil {
+			log.Fatalf("Error loading saved state: %v", err)
+		}
+		if reminderStore, err = NewBoltReminderStore(db); err != nil {
+			log.Fatalf("Error loading saved state: %v", err)
+		}
+	}
+
+	// Due date reminders, sent in DM
+	if cfg.Reminders.Enabled {
+		if err := startReminders(dg, cfg.Reminders, reminderStore, stop); err != nil {
+			log.Fatalf("Error starting reminders: %v", err)
+		}
+	}
+
+	// Keep handles and reminders up to date with every change made through the bot
+	observers := todo_utils.Observers{handleObserver{}}
+	if reminders != nil {
+		observers = append(observers, reminders)
+	}
+	TodoApp.Observer = observers
 
 	// 2. DEFINE INTENTS
 	// We need IntentsGuildMessages to receive message events.
@@ -73,7 +138,8 @@ func Start(token string, service llm_utils.LLMService) {
 	// This is how the bot "waits for" and reacts to incoming messages.
 	dg.AddHandler(messageCreate)
 
-	// Add a handler for the InteractionCreate event, which fires when a user interacts with components.
+	// Add a handler for the InteractionCreate event, which fires when a user runs a slash command
+	// or interacts with components.
 	dg.AddHandler(interactionCreate)
 
 	// 4. OPEN WEBSOCKET CONNECTION
@@ -83,6 +149,14 @@ func Start(token string, service llm_utils.LLMService) {
 	}
 	defer dg.Close()
 
+	// Register slash commands now that we know our application ID
+	if err := registerSlashCommands(dg); err != nil {
+		log.Printf("Error registering slash commands: %v", err)
+	}
+
+	// Daily digests go out once we are connected
+	go runDigests(dg, stop)
+
 	// 5. WAIT FOR SHUTDOWN SIGNAL
 	fmt.Println("Bot is now running. Press CTRL-C to exit.")
 	sc := make(chan os.Signal, 1)
@@ -120,589 +194,52 @@ func messageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
 		return
 	}
 
-	// Get the DM channel for the user
-	dmChannel, err := s.UserChannelCreate(m.Author.ID)
-	if err != nil {
-		// If we can't create a DM channel, fall back to the original channel
-		log.Printf("Failed to create DM channel for user %s: %v", m.Author.ID, err)
-		dmChannel = &discordgo.Channel{ID: m.ChannelID}
-	}
-
 	// Check if this user is in a conversation
-	if state, exists := userStates[m.Author.ID]; exists {
-		switch state.Action {
-		case "create":
-			switch state.Step {
-			// Step 1: Get Title
-			case 1:
-				state.TaskTitle = m.Content
-				state.Step = 2
-				s.ChannelMessageSend(dmChannel.ID, "Got it ✅ Now, what’s the status? (backlog, in-progress, done)")
-
-			// Step 2: Get Status & Create Task
-			case 2:
-				status := m.Content
-
-				response, err := TodoApp.CreateTask(state.TaskTitle, status, m.Author.ID)
-				if err != nil {
-					s.ChannelMessageSend(dmChannel.ID, fmt.Sprintf("❌ %v", err))
-					s.ChannelMessageSend(dmChannel.ID, "Try Again")
-
-				} else {
-					s.ChannelMessageSend(dmChannel.ID, fmt.Sprintf("✅ Task Created: %s \n", state.TaskTitle))
-					s.ChannelMessageSend(dmChannel.ID, fmt.Sprintf(":ledger: Task Status: %s \n", status))
-
-					s.ChannelMessageSend(dmChannel.ID, fmt.Sprintf(":debug response: %s \n", response))
-
-				}
-
-				// End conversation
-				delete(userStates, m.Author.ID)
-			}
-
-		case "update":
-			switch state.Step {
-			// Step 1: Get Title
-			case 1:
-				if strings.ToLower(m.Content) != "skip" {
-					state.TaskTitle = m.Content
-				}
-				state.Step = 2
-				s.ChannelMessageSend(dmChannel.ID, "Got it ✅ Now, what's the status? (backlog, in-progress, done) (Type 'skip' to keep the current status)")
-
-			// Step 2: Get Status & Update Task
-			case 2:
-				if strings.ToLower(m.Content) != "skip" {
-					state.TaskStatus = m.Content
-				}
-				state.Step = 3
-
-				// Look up the actual task ID using the friendly number
-				paginationState, exists := userPagination[m.Author.ID]
-				if !exists || paginationState.TaskIDMap == nil {
-					s.ChannelMessageSend(dmChannel.ID, "❌ Error: Task list not found. Please run `!todo-list` first.")
-					delete(userStates, m.Author.ID)
-					return
-				}
-
-				taskID, taskExists := paginationState.TaskIDMap[state.TaskNumber]
-				if !taskExists {
-					state.Attempts++
-					if state.Attempts >= 3 {
-						s.ChannelMessageSend(dmChannel.ID, "❌ Too many invalid attempts. Please run `!todo-list` to see the current task numbers.")
-						delete(userStates, m.Author.ID)
-						return
-					}
-					s.ChannelMessageSend(dmChannel.ID, fmt.Sprintf("❌ Invalid task number. Please try again. (%d/3 attempts)", state.Attempts))
-					state.Step = 1 // Reset to step 1 to ask for title again
-					s.ChannelMessageSend(dmChannel.ID, "📝 Let's update your task! What's the new title? (Type 'skip' to keep the current title)")
-					return
-				}
-
-				// Use existing title/status if not provided
-				title := state.TaskTitle
-				status := state.TaskStatus
-
-				// If title or status is empty, we need to get the current values
-				// For simplicity, we'll just use empty strings and let the API handle defaults
-				// In a production app, you might want to fetch the current task details first
-
-				response, err := TodoApp.UpdateTask(taskID, title, status, m.Author.ID)
-				if err != nil {
-					s.ChannelMessageSend(dmChannel.ID, fmt.Sprintf("❌ %v", err))
-					s.ChannelMessageSend(dmChannel.ID, "Try Again")
-
-				} else {
-					s.ChannelMessageSend(dmChannel.ID, fmt.Sprintf("✅ Task Updated: %s \n", title))
-					s.ChannelMessageSend(dmChannel.ID, fmt.Sprintf(":ledger: New Task Status: %s \n", status))
-
-					s.ChannelMessageSend(dmChannel.ID, fmt.Sprintf(":debug response: %s \n", response))
-
-				}
-
-				// End conversation
-				delete(userStates, m.Author.ID)
-			}
-
-		case "delete":
-			switch state.Step {
-			// Step 1: Confirm deletion
-			case 1:
-				if strings.ToLower(m.Content) == "yes" {
-					// Look up the actual task ID using the friendly number
-					paginationState, exists := userPagination[m.Author.ID]
-					if !exists || paginationState.TaskIDMap == nil {
-						s.ChannelMessageSend(dmChannel.ID, "❌ Error: Task list not found. Please run `!todo-list` first.")
-						delete(userStates, m.Author.ID)
-						return
-					}
-
-					taskID, taskExists := paginationState.TaskIDMap[state.TaskNumber]
-					if !taskExists {
-						state.Attempts++
-						if state.Attempts >= 3 {
-							s.ChannelMessageSend(dmChannel.ID, "❌ Too many invalid attempts. Please run `!todo-list` to see the current task numbers.")
-							delete(userStates, m.Author.ID)
-							return
-						}
-						s.ChannelMessageSend(dmChannel.ID, fmt.Sprintf("❌ Invalid task number. Please try again. (%d/3 attempts)", state.Attempts))
-						s.ChannelMessageSend(dmChannel.ID, "🗑️ Are you sure you want to delete this task? Type 'yes' to confirm or 'no' to cancel.")
-						return
-					}
-
-					response, err := TodoApp.DeleteTask(taskID, m.Author.ID)
-					if err != nil {
-						s.ChannelMessageSend(dmChannel.ID, fmt.Sprintf("❌ %v", err))
-						s.ChannelMessageSend(dmChannel.ID, "Try Again")
-
-					} else {
-						s.ChannelMessageSend(dmChannel.ID, fmt.Sprintf("✅ Task Deleted Successfully\n"))
-						s.ChannelMessageSend(dmChannel.ID, fmt.Sprintf(":debug response: %s \n", response))
-					}
-				} else {
-					s.ChannelMessageSend(dmChannel.ID, "🗑️ Task deletion cancelled.")
-				}
-
-				// End conversation
-				delete(userStates, m.Author.ID)
-			}
-		}
+	if state, exists := userStates.Get(m.Author.ID); exists {
+		continueWizard(s, m, &state)
 		return
 	}
 
-	// If the message content is "!ping", reply with "Pong!"
-	if strings.HasPrefix(m.Content, "!ping ") {
-		s.ChannelMessageSend(m.ChannelID, "Pong!")
-
-		// DEBUG BLOCK. PLEASE COMMENT THIS LINE ON PRODUCTION
-		msgBytes, err := json.MarshalIndent(m, "", "  ")
-		if err != nil {
-			fmt.Println("Error marshaling message:", err)
-		} else {
-			fmt.Println(string(msgBytes))
-		}
-		fmt.Printf("Responded to !ping from %s in channel %s\n", m.Author.Username, m.ChannelID)
-	}
-
-	// If the message content is "!hello", reply with a greeting.
-	if strings.HasPrefix(m.Content, "!hello ") {
-		reply := fmt.Sprintf("Hello, %s!", m.Author.Username)
-		s.ChannelMessageSend(m.ChannelID, reply)
-		fmt.Printf("Responded to !hello from %s in channel %s\n", m.Author.Username, m.ChannelID)
-	}
-
-	// Helper function to show task list
-	showTaskList := func() {
-		// Set default page to 1
-		page := 1
-
-		// Check if user has an existing pagination state
-		if state, exists := userPagination[m.Author.ID]; exists {
-			page = state.Page
-		} else {
-			// Initialize pagination state
-			userPagination[m.Author.ID] = &PaginationState{Page: 1, TaskIDMap: make(map[int]string)}
-		}
-
-		// Fetch tasks from API with default limit of 5
-		taskResponse, err := TodoApp.GetTasks(m.Author.ID, page, 5)
-		if err != nil {
-			s.ChannelMessageSend(dmChannel.ID, fmt.Sprintf("❌ Error fetching tasks: %v", err))
-			return
-		}
-
-		// Format the response message
-		if len(taskResponse.Tasks) == 0 {
-			s.ChannelMessageSend(dmChannel.ID, "📭 You have no tasks yet. Use `!todo-create` to add some!")
-			return
-		}
-
-		// Initialize or reset the task ID map for this page
-		userPagination[m.Author.ID].TaskIDMap = make(map[int]string)
-
-		// Build the task list message
-		message := fmt.Sprintf("**📋 Your Todo List (Page %d/%d)**\n\n", taskResponse.Page, taskResponse.TotalPages)
-
-		for i, task := range taskResponse.Tasks {
-			// Calculate the friendly number for this task
-			friendlyNumber := (i + 1) + ((page - 1) * 5)
-
-			// Store the mapping between friendly number and actual task ID
-			userPagination[m.Author.ID].TaskIDMap[friendlyNumber] = task.ID
-
-			// Add emoji based on status
-			statusEmoji := "📝"
-			switch task.Status {
-			case "done":
-				statusEmoji = "✅"
-			case "in-progress":
-				statusEmoji = "🔄"
-			case "backlog":
-				statusEmoji = "📥"
-			}
-
-			message += fmt.Sprintf("`%d.` %s **%s** (%s)\n",
-				friendlyNumber,
-				statusEmoji,
-				task.Title,
-				task.Status)
-		}
-
-		message += fmt.Sprintf("\n📄 Page %d of %d | Total tasks: %d\n", taskResponse.Page, taskResponse.TotalPages, taskResponse.Total)
-
-		// Add navigation buttons
-		components := []discordgo.MessageComponent{}
-
-		// Show previous button unless we're on the first page
-		if taskResponse.Page > 1 {
-			components = append(components, discordgo.Button{
-				Label:    "⬅️ Previous",
-				Style:    discordgo.PrimaryButton,
-				CustomID: fmt.Sprintf("todo_prev_%d", page-1),
-			})
-		}
-
-		// Show next button unless we're on the last page
-		if taskResponse.Page < taskResponse.TotalPages {
-			components = append(components, discordgo.Button{
-				Label:    "Next ➡️",
-				Style:    discordgo.PrimaryButton,
-				CustomID: fmt.Sprintf("todo_next_%d", page+1),
-			})
-		}
-
-		// Create actions row if we have buttons
-		actions := []discordgo.MessageComponent{}
-		if len(components) > 0 {
-			actions = append(actions, discordgo.ActionsRow{
-				Components: components,
-			})
-		}
-
-		// Send message with navigation buttons
-		if len(actions) > 0 {
-			s.ChannelMessageSendComplex(dmChannel.ID, &discordgo.MessageSend{
-				Content:    message,
-				Components: actions,
-			})
-		} else {
-			s.ChannelMessageSend(dmChannel.ID, message)
-		}
-	}
-
-	if strings.HasPrefix(m.Content, "!summarize ") {
-		// Get the text after the command by removing the prefix.
-		textToSummarize := strings.TrimPrefix(m.Content, "!summarize ")
-
-		// Optional: Check if the user actually provided any text.
-		if textToSummarize == "" {
-			s.ChannelMessageSend(m.ChannelID, "Please provide some text to summarize after the command.")
-			return
-		}
-
-		// You now have the text!
-		fmt.Printf("User %s wants to summarize: '%s'\n", m.Author.Username, textToSummarize)
-
-		// Here you would add your logic to process the text.
-		// For now, let's just send it back.
-		reply := fmt.Sprintf("Okay, I will summarize this for you in. Please wait")
-		s.ChannelMessageSend(m.ChannelID, reply)
-		summary, err := llmService.SummarizeFromText(textToSummarize)
-		if err != nil {
-			log.Printf("Error getting summary: %v", err)
-			// Handle the error, maybe send a message back to Discord
-		} else {
-			reply := fmt.Sprintf(summary)
-			s.ChannelMessageSend(m.ChannelID, reply)
-		}
-	}
-
-	if strings.HasPrefix(m.Content, "!help") {
-		reply := fmt.Sprintf("Hey %s! 👋 I'm WinayaBot, here to help you manage your tasks and more!\n\n"+
-			"**General Commands:**\n"+
-			"• `!ping` - Check if I'm alive\n"+
-			"• `!hello` - Get a friendly greeting\n"+
-			"• `!help` - Show this help message\n"+
-			"• `!summarize <text>` - Summarize a long piece of text\n"+
-			"• `!summarize-link <url>` - Summarize the content of a webpage\n\n"+
-			"**Task Management:**\n"+
-			"• `!todo-create` - Create a new task\n"+
-			"• `!todo-list` - View your tasks (with pagination)\n"+
-			"• `!todo-update <number>` - Update a task (use the number from !todo-list)\n"+
-			"• `!todo-delete <number>` - Delete a task (use the number from !todo-list)\n\n"+
-			"Just type any command to get started!", m.Author.GlobalName)
-		s.ChannelMessageSend(m.ChannelID, reply)
-		fmt.Printf("Responded to !help from %s in channel %s\n", m.Author.Username, m.ChannelID)
-	}
-
-	if strings.HasPrefix(m.Content, "!summarize-link ") {
-		// 1. Get the URL from the message
-		url := strings.TrimPrefix(m.Content, "!summarize-link ")
-		if url == "" {
-			s.ChannelMessageSend(m.ChannelID, "Tolong berikan URL yang valid.")
-			return
-		}
-
-		s.ChannelMessageSend(m.ChannelID, "Mengakses halaman web... Mohon tunggu.")
-
-		// 2. Call your function to get the webpage content
-		pageContent, err := llmService.ReadWebPages(url)
-		if err != nil {
-			log.Printf("Error reading webpage: %v", err)
-			s.ChannelMessageSend(m.ChannelID, "Maaf, gagal mengakses URL tersebut.")
-			return
-		}
-
-		// This is a good check in case the page was empty
-		if pageContent == "" {
-			s.ChannelMessageSend(m.ChannelID, "Halaman web tersebut tidak memiliki konten yang bisa dibaca.")
-			return
-		}
-
-		s.ChannelMessageSend(m.ChannelID, "Halaman berhasil diakses. Sekarang, saya akan meringkas isinya...")
-
-		// 3. Feed the page content into your summarizer
-		summary, err := llmService.SummarizeFromText(pageContent)
-		if err != nil {
-			log.Printf("Error from LLM service on webpage content: %v", err)
-			s.ChannelMessageSend(m.ChannelID, "Maaf, terjadi kesalahan saat meringkas konten halaman web.")
-			return
-		}
-
-		// 4. Send the final summary to the user
-		s.ChannelMessageSend(m.ChannelID, "**Berikut ringkasan dari halaman web:**\n"+summary)
-	}
-
-	if strings.HasPrefix(m.Content, "!todo-create") {
-		// Check if this is already a DM channel
-		channel, err := s.State.Channel(m.ChannelID)
-		if err == nil && channel.Type != discordgo.ChannelTypeDM {
-			// Only notify in server channels, not DMs
-			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("<@%s> Please check your DMs to create a new task!", m.Author.ID))
-		}
-		// Start the conversation
-		userStates[m.Author.ID] = &ConversationState{Step: 1, Action: "create"}
-		s.ChannelMessageSend(dmChannel.ID, "📝 Let's create a new task! What's the title?")
+	if commandRouter.Dispatch(s, m) {
 		return
 	}
 
-	if strings.HasPrefix(m.Content, "!todo-update") {
-		// Check if this is already a DM channel
-		channel, err := s.State.Channel(m.ChannelID)
-		if err == nil && channel.Type != discordgo.ChannelTypeDM {
-			// Only notify in server channels, not DMs
-			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("<@%s> Please check your DMs to update a task!", m.Author.ID))
-		}
-		// Check if the command is exactly "!todo-update" with no arguments
-		if strings.TrimSpace(m.Content) == "!todo-update" {
-			// No number provided, show the task list automatically
-			s.ChannelMessageSend(dmChannel.ID, "No task number provided. Here's your task list:")
-			showTaskList()
-			return
-		}
+	// Mentioning the bot talks to the assistant, like !ask
+	if text, ok := mentionedBot(s, m); ok {
+		ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+		defer cancel()
+		askCommand(&CommandContext{Ctx: ctx, Session: s, Message: m, RawArgs: text})
+	}
+}
 
-		// Extract task number from command
-		taskNumberStr := strings.TrimPrefix(m.Content, "!todo-update ")
-		if taskNumberStr == "" {
-			// No number provided, show the task list automatically
-			s.ChannelMessageSend(dmChannel.ID, "No task number provided. Here's your task list:")
-			showTaskList()
-			return
+// interactionCreate handles slash commands, buttons on the todo list, reminders and confirmations, and modal submissions
+func interactionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		if handler, ok := slashHandlers[i.ApplicationCommandData().Name]; ok {
+			handler(s, i)
 		}
 
-		// Convert to integer
-		taskNumber, err := strconv.Atoi(taskNumberStr)
-		if err != nil {
-			s.ChannelMessageSend(dmChannel.ID, "❌ Please provide a valid task number. Usage: `!todo-update <number>`")
+	case discordgo.InteractionMessageComponent:
+		if strings.HasPrefix(i.MessageComponentData().CustomID, "remind_") {
+			handleReminderComponent(s, i)
 			return
 		}
-
-		// Start the update conversation
-		userStates[m.Author.ID] = &ConversationState{Step: 1, TaskNumber: taskNumber, Action: "update", Attempts: 0}
-		s.ChannelMessageSend(dmChannel.ID, "📝 Let's update your task! What's the new title? (Type 'skip' to keep the current title)")
-		return
-	}
-
-	if strings.HasPrefix(m.Content, "!todo-delete") {
-		// Check if this is already a DM channel
-		channel, err := s.State.Channel(m.ChannelID)
-		if err == nil && channel.Type != discordgo.ChannelTypeDM {
-			// Only notify in server channels, not DMs
-			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("<@%s> Please check your DMs to delete a task!", m.Author.ID))
-		}
-		// Check if the command is exactly "!todo-delete" with no arguments
-		if strings.TrimSpace(m.Content) == "!todo-delete" {
-			// No number provided, show the task list automatically
-			s.ChannelMessageSend(dmChannel.ID, "No task number provided. Here's your task list:")
-			showTaskList()
+		if strings.HasPrefix(i.MessageComponentData().CustomID, "undo_") {
+			handleUndoComponent(s, i)
 			return
 		}
-
-		// Extract task number from command
-		taskNumberStr := strings.TrimPrefix(m.Content, "!todo-delete ")
-		if taskNumberStr == "" {
-			// No number provided, show the task list automatically
-			s.ChannelMessageSend(dmChannel.ID, "No task number provided. Here's your task list:")
-			showTaskList()
+		if strings.HasPrefix(i.MessageComponentData().CustomID, "draft_") {
+			handleDraftComponent(s, i)
 			return
 		}
-
-		// Convert to integer
-		taskNumber, err := strconv.Atoi(taskNumberStr)
-		if err != nil {
-			s.ChannelMessageSend(dmChannel.ID, "❌ Please provide a valid task number. Usage: `!todo-delete <number>`")
+		if strings.HasPrefix(i.MessageComponentData().CustomID, "agent_") {
+			handleAgentComponent(s, i)
 			return
 		}
+		handleTodoComponent(s, i)
 
-		// Start the delete conversation
-		userStates[m.Author.ID] = &ConversationState{Step: 1, TaskNumber: taskNumber, Action: "delete", Attempts: 0}
-		s.ChannelMessageSend(dmChannel.ID, "🗑️ Are you sure you want to delete this task? Type 'yes' to confirm or 'no' to cancel.")
-		return
-	}
-
-	if strings.HasPrefix(m.Content, "!todo-list") {
-		// Check if this is already a DM channel
-		channel, err := s.State.Channel(m.ChannelID)
-		if err == nil && channel.Type != discordgo.ChannelTypeDM {
-			// Only notify in server channels, not DMs
-			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("<@%s> Please check your DMs for your task list!", m.Author.ID))
-		}
-		showTaskList()
-	}
-
-}
-
-// interactionCreate handles button interactions for pagination
-func interactionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	// Check if the interaction is a button click
-	if i.Type == discordgo.InteractionMessageComponent {
-		customID := i.MessageComponentData().CustomID
-
-		// Check if it's a todo pagination button
-		if strings.HasPrefix(customID, "todo_") {
-			// Extract action and page number
-			parts := strings.Split(customID, "_")
-			if len(parts) != 3 {
-				return
-			}
-
-			// action := parts[1] // "prev" or "next" (not used)
-			page, err := strconv.Atoi(parts[2])
-			if err != nil {
-				return
-			}
-
-			// Update user's pagination state
-			// For DM interactions, i.Member might be nil, so we use i.User.ID instead
-			userID := ""
-			if i.Member != nil {
-				userID = i.Member.User.ID
-			} else if i.User != nil {
-				userID = i.User.ID
-			} else {
-				// If we can't identify the user, return
-				return
-			}
-			if _, exists := userPagination[userID]; !exists {
-				userPagination[userID] = &PaginationState{TaskIDMap: make(map[int]string)}
-			}
-			userPagination[userID].Page = page
-
-			// Fetch tasks from API
-			taskResponse, err := TodoApp.GetTasks(userID, page, 5)
-			if err != nil {
-				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-					Type: discordgo.InteractionResponseChannelMessageWithSource,
-					Data: &discordgo.InteractionResponseData{
-						Content: fmt.Sprintf("❌ Error fetching tasks: %v", err),
-						Flags:   discordgo.MessageFlagsEphemeral,
-					},
-				})
-				return
-			}
-
-			// Format the response message
-			if len(taskResponse.Tasks) == 0 {
-				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-					Type: discordgo.InteractionResponseUpdateMessage,
-					Data: &discordgo.InteractionResponseData{
-						Content: "📭 You have no tasks yet. Use `!todo-create` to add some!",
-					},
-				})
-				return
-			}
-
-			// Reset the task ID map for this page
-			userPagination[userID].TaskIDMap = make(map[int]string)
-
-			// Build the task list message
-			message := fmt.Sprintf("**📋 Your Todo List (Page %d/%d)**\n\n", taskResponse.Page, taskResponse.TotalPages)
-
-			for i, task := range taskResponse.Tasks {
-				// Calculate the friendly number for this task
-				friendlyNumber := (i + 1) + ((page - 1) * 5)
-
-				// Store the mapping between friendly number and actual task ID
-				userPagination[userID].TaskIDMap[friendlyNumber] = task.ID
-
-				// Add emoji based on status
-				statusEmoji := "📝"
-				switch task.Status {
-				case "done":
-					statusEmoji = "✅"
-				case "in-progress":
-					statusEmoji = "🔄"
-				case "backlog":
-					statusEmoji = "📥"
-				}
-
-				message += fmt.Sprintf("`%d.` %s **%s** (%s)\n",
-					friendlyNumber,
-					statusEmoji,
-					task.Title,
-					task.Status)
-			}
-
-			message += fmt.Sprintf("\n📄 Page %d of %d | Total tasks: %d\n", taskResponse.Page, taskResponse.TotalPages, taskResponse.Total)
-			message += "Use `!todo-update <number>` or `!todo-delete <number>` to modify tasks\n"
-
-			// Add navigation buttons
-			components := []discordgo.MessageComponent{}
-
-			// Show previous button unless we're on the first page
-			if taskResponse.Page > 1 {
-				components = append(components, discordgo.Button{
-					Label:    "⬅️ Previous",
-					Style:    discordgo.PrimaryButton,
-					CustomID: fmt.Sprintf("todo_prev_%d", page-1),
-				})
-			}
-
-			// Show next button unless we're on the last page
-			if taskResponse.Page < taskResponse.TotalPages {
-				components = append(components, discordgo.Button{
-					Label:    "Next ➡️",
-					Style:    discordgo.PrimaryButton,
-					CustomID: fmt.Sprintf("todo_next_%d", page+1),
-				})
-			}
-
-			// Create actions row if we have buttons
-			actions := []discordgo.MessageComponent{}
-			if len(components) > 0 {
-				actions = append(actions, discordgo.ActionsRow{
-					Components: components,
-				})
-			}
-
-			// Respond to the interaction with updated message
-			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseUpdateMessage,
-				Data: &discordgo.InteractionResponseData{
-					Content:    message,
-					Components: actions,
-				},
-			})
-		}
+	case discordgo.InteractionModalSubmit:
+		handleTodoModal(s, i)
 	}
 }
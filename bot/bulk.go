@@ -0,0 +1,296 @@
+package bot
+
+import (
+	todo_utils "Discord_bot_v1/todo-utils"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	// bulkParallelism is how many backend calls a bulk operation makes at once
+	bulkParallelism = 4
+	// bulkMaxTasks is how many tasks one bulk operation may touch
+	bulkMaxTasks = 50
+)
+
+// Bulk operations, stored in the "action" of the bulk wizard
+const (
+	bulkActionDelete = "delete"
+	bulkActionDone   = "done"
+)
+
+// parseTaskSelection parses one or more tasks, e.g. "1,3,5-8", "2 4 6" or "T-3 T-7".
+// A single task, including a quoted title, is parsed like parseTaskRef.
+// It yields a taskRef for one task and a []taskRef for several.
+func parseTaskSelection(raw string) (interface{}, error) {
+	tokens := strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	var refs []taskRef
+	for _, token := range tokens {
+		if handle, ok := parseHandle(token); ok {
+			refs = append(refs, taskRef{Handle: handle})
+			continue
+		}
+
+		from, to, isRange := strings.Cut(token, "-")
+		first, err := strconv.Atoi(from)
+		if err != nil || first < 1 {
+			// Not a list of numbers and handles, so it is a single title or ID
+			return parseTaskRef(raw)
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(to); err != nil {
+				return parseTaskRef(raw)
+			}
+			if last < first {
+				return nil, fmt.Errorf("%q is backwards, use something like 5-8", token)
+			}
+		}
+		if len(refs)+last-first+1 > bulkMaxTasks {
+			return nil, fmt.Errorf("that's more than %d tasks at once", bulkMaxTasks)
+		}
+		for n := first; n <= last; n++ {
+			refs = append(refs, taskRef{Number: n})
+		}
+	}
+
+	if len(refs) == 1 {
+		return refs[0], nil
+	}
+	return refs, nil
+}
+
+// parseClearArgs parses the filter of !todo-clear. A filter is required, so a
+// typo can't clear every task.
+func parseClearArgs(raw string) (interface{}, error) {
+	query, err := parseTaskQuery(raw)
+	if err != nil {
+		return nil, err
+	}
+	query.Sort = ""
+	if query.IsZero() {
+		return nil, errors.New("tell me which tasks to clear, e.g. status:done")
+	}
+	return query, nil
+}
+
+// resolveSelection looks up the tasks behind numbers, handles and titles.
+// If any of them can't be found, the user is told which and nothing is returned.
+func resolveSelection(ctx *CommandContext, refs []taskRef) ([]todo_utils.Task, bool) {
+	userID := ctx.Message.Author.ID
+	tasks, err := fetchAllTasks(ctx.Ctx, userID)
+	if err != nil {
+		ctx.SendDM(todoErrorMessage(err))
+		return nil, false
+	}
+	byID := make(map[string]todo_utils.Task, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+
+	var selected []todo_utils.Task
+	var unknown []string
+	seen := make(map[string]bool)
+	for _, ref := range refs {
+		taskID, label := "", ref.Text
+		switch {
+		case ref.Number > 0:
+			taskID, _ = lookupTaskID(userID, ref.Number)
+			label = strconv.Itoa(ref.Number)
+		case ref.Handle > 0:
+			taskID, _ = lookupHandle(userID, ref.Handle)
+			label = formatHandle(ref.Handle)
+		default:
+			found, err := findTasksByText(ctx.Ctx, userID, ref)
+			if err != nil {
+				ctx.SendDM(todoErrorMessage(err))
+				return nil, false
+			}
+			if len(found) > 1 {
+				ctx.SendDM(fmt.Sprintf("🔎 %d tasks match **%s**, use their numbers or handles instead.", len(found), ref.Text))
+				return nil, false
+			}
+			if len(found) == 1 {
+				taskID = found[0].ID
+			}
+		}
+
+		task, ok := byID[taskID]
+		if !ok {
+			unknown = append(unknown, label)
+			continue
+		}
+		if !seen[taskID] {
+			seen[taskID] = true
+			selected = append(selected, task)
+		}
+	}
+
+	if len(unknown) > 0 {
+		ctx.SendDM(fmt.Sprintf("❌ I couldn't find %s. Run `%stodo-list` to see the current numbers and handles.", strings.Join(unknown, ", "), commandRouter.Prefix))
+		return nil, false
+	}
+	return selected, true
+}
+
+// confirmBulk lists the tasks an operation will touch and asks for a single confirmation
+func confirmBulk(ctx *CommandContext, action string, tasks []todo_utils.Task) {
+	userID := ctx.Message.Author.ID
+
+	if action == bulkActionDone {
+		// Nothing to do for tasks that are already done
+		open := tasks[:0:0]
+		for _, task := range tasks {
+			if task.Status != "done" {
+				open = append(open, task)
+			}
+		}
+		tasks = open
+	}
+	if len(tasks) == 0 {
+		ctx.SendDM("🤷 There are no tasks to change.")
+		return
+	}
+	if len(tasks) > bulkMaxTasks {
+		ctx.SendDM(fmt.Sprintf("❌ That's %d tasks, I can only do %d at once. Narrow it down a bit.", len(tasks), bulkMaxTasks))
+		return
+	}
+
+	heading := fmt.Sprintf("🗑️ **Delete %d %s?**", len(tasks), pluralTasks(len(tasks)))
+	if action == bulkActionDone {
+		heading = fmt.Sprintf("✅ **Mark %d %s as done?**", len(tasks), pluralTasks(len(tasks)))
+	}
+
+	lines := []string{heading, ""}
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+		lines = append(lines, fmt.Sprintf("• %s %s %s", handleLabel(userID, &task), statusEmoji(task.Status), truncate(task.Title, 80)))
+	}
+	ctx.SendDM(truncate(strings.Join(lines, "\n"), 2000))
+
+	startWizard(ctx.Session, userID, ctx.DM(), "bulk", map[string]string{
+		"action":   action,
+		"task_ids": strings.Join(ids, ","),
+	})
+}
+
+// bulkResult is the outcome of a bulk operation on one task
+type bulkResult struct {
+	TaskID string
+	// Task is the task as it was before, nil if it couldn't be fetched
+	Task *todo_utils.Task
+	Err  error
+}
+
+// runBulk applies the action to every task, at most bulkParallelism at a time.
+// Every task gets its own commandTimeout, so a long selection doesn't starve the last ones.
+// Results are in the order of taskIDs. The changes are recorded as a single undo entry,
+// so one !todo-undo reverts them all without pushing older changes out of the history.
+func runBulk(userID string, action string, taskIDs []string) []bulkResult {
+	results := make([]bulkResult, len(taskIDs))
+	items := make([]*UndoEntry, len(taskIDs))
+	sem := make(chan struct{}, bulkParallelism)
+	var wg sync.WaitGroup
+
+	for i, taskID := range taskIDs {
+		wg.Add(1)
+		go func(i int, taskID string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+			defer cancel()
+
+			result := bulkResult{TaskID: taskID}
+			result.Task, result.Err = TodoApp.GetTask(ctx, taskID, userID)
+			if result.Err == nil {
+				var entry UndoEntry
+				switch action {
+				case bulkActionDelete:
+					entry = newUndoEntry(userID, undoActionDelete, result.Task)
+					_, result.Err = TodoApp.DeleteTask(ctx, taskID, userID)
+				case bulkActionDone:
+					entry = newUndoEntry(userID, undoActionUpdate, result.Task)
+					input := result.Task.Input()
+					input.Status = "done"
+					_, result.Err = TodoApp.UpdateTask(ctx, taskID, input, userID)
+				}
+				if result.Err == nil {
+					items[i] = &entry
+				}
+			}
+			results[i] = result
+		}(i, taskID)
+	}
+
+	wg.Wait()
+
+	bulk := UndoEntry{ID: strconv.FormatInt(time.Now().UnixNano(), 36), Action: undoActionBulk, At: time.Now()}
+	for _, item := range items {
+		if item != nil {
+			bulk.Items = append(bulk.Items, *item)
+		}
+	}
+	if len(bulk.Items) > 0 {
+		saveUndo(userID, bulk)
+	}
+	return results
+}
+
+// commitBulk runs a confirmed bulk operation and reports how every task fared
+func commitBulk(ctx *WizardContext) error {
+	if strings.ToLower(ctx.Data["confirm"]) != "yes" {
+		ctx.Send("🛑 Nothing was changed.")
+		return nil
+	}
+
+	action := ctx.Data["action"]
+	results := runBulk(ctx.UserID, action, strings.Split(ctx.Data["task_ids"], ","))
+
+	verb := "Deleted"
+	if action == bulkActionDone {
+		verb = "Marked done"
+	}
+
+	failed := 0
+	lines := []string{}
+	for _, result := range results {
+		title := result.TaskID
+		if result.Task != nil {
+			title = truncate(result.Task.Title, 80)
+		}
+		if result.Err != nil {
+			failed++
+			lines = append(lines, fmt.Sprintf("❌ %s: %s", title, strings.TrimPrefix(todoErrorMessage(result.Err), "❌ ")))
+			continue
+		}
+		lines = append(lines, "✅ "+title)
+	}
+
+	summary := fmt.Sprintf("**%s %d of %d %s.**", verb, len(results)-failed, len(results), pluralTasks(len(results)))
+	if failed < len(results) {
+		summary += fmt.Sprintf(" Use `%stodo-undo` to revert them all at once.", commandRouter.Prefix)
+	}
+	ctx.Send(truncate(summary+"\n\n"+strings.Join(lines, "\n"), 2000))
+	return nil
+}
+
+// pluralTasks is "task" or "tasks" for n
+func pluralTasks(n int) string {
+	if n == 1 {
+		return "task"
+	}
+	return "tasks"
+}
@@ -0,0 +1,55 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func init() {
+	commandRouter.Register(&Command{
+		Name:        "ping",
+		Description: "Check if I'm alive",
+		Category:    "General Commands",
+		Handler:     pingCommand,
+	})
+	commandRouter.Register(&Command{
+		Name:        "hello",
+		Description: "Get a friendly greeting",
+		Category:    "General Commands",
+		Handler:     helloCommand,
+	})
+	commandRouter.Register(&Command{
+		Name:        "help",
+		Description: "Show this help message",
+		Category:    "General Commands",
+		Handler:     helpCommand,
+	})
+}
+
+// pingCommand replies with "Pong!"
+func pingCommand(ctx *CommandContext) {
+	ctx.Reply("Pong!")
+
+	// Dump the whole message, only with log_level: debug
+	if debugLogging {
+		msgBytes, err := json.MarshalIndent(ctx.Message, "", "  ")
+		if err != nil {
+			fmt.Println("Error marshaling message:", err)
+		} else {
+			fmt.Println(string(msgBytes))
+		}
+	}
+	fmt.Printf("Responded to !ping from %s in channel %s\n", ctx.Message.Author.Username, ctx.Message.ChannelID)
+}
+
+// helloCommand replies with a greeting
+func helloCommand(ctx *CommandContext) {
+	ctx.Reply(fmt.Sprintf("Hello, %s!", ctx.Message.Author.Username))
+	fmt.Printf("Responded to !hello from %s in channel %s\n", ctx.Message.Author.Username, ctx.Message.ChannelID)
+}
+
+// helpCommand lists every registered command
+func helpCommand(ctx *CommandContext) {
+	ctx.Reply(commandRouter.HelpText(ctx.Message.Author.GlobalName))
+	fmt.Printf("Responded to !help from %s in channel %s\n", ctx.Message.Author.Username, ctx.Message.ChannelID)
+}
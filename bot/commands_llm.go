@@ -0,0 +1,126 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// llmOffMessage answers LLM commands while no provider is configured
+const llmOffMessage = "🤖 Summaries are turned off on this bot."
+
+// streamEditInterval is how often a streamed reply is edited, within Discord's rate limit for edits
+const streamEditInterval = time.Second
+
+func init() {
+	commandRouter.Register(&Command{
+		Name:        "summarize",
+		Usage:       "<text>",
+		Description: "Summarize a long piece of text",
+		Category:    "General Commands",
+		Handler:     summarizeCommand,
+	})
+	commandRouter.Register(&Command{
+		Name:        "summarize-link",
+		Usage:       "<url>",
+		Description: "Summarize the content of a webpage",
+		Category:    "General Commands",
+		Handler:     summarizeLinkCommand,
+	})
+}
+
+// summarizeCommand summarizes the text after the command
+func summarizeCommand(ctx *CommandContext) {
+	textToSummarize := ctx.RawArgs
+
+	// Check if the user actually provided any text.
+	if textToSummarize == "" {
+		ctx.Reply("Please provide some text to summarize after the command.")
+		return
+	}
+	if llmService == nil {
+		ctx.Reply(llmOffMessage)
+		return
+	}
+
+	fmt.Printf("User %s wants to summarize: '%s'\n", ctx.Message.Author.Username, textToSummarize)
+
+	// The summary is streamed into this message as it is written
+	message, err := ctx.Session.ChannelMessageSend(ctx.Message.ChannelID, "Okay, I will summarize this for you. Please wait…")
+	if err != nil {
+		log.Printf("Error sending summary placeholder: %v", err)
+		return
+	}
+	edit := func(content string) {
+		if _, err := ctx.Session.ChannelMessageEdit(message.ChannelID, message.ID, truncate(content, 2000)); err != nil {
+			log.Printf("Error editing summary: %v", err)
+		}
+	}
+
+	llmCtx, cancel := context.WithTimeout(context.Background(), llmTimeout)
+	defer cancel()
+
+	var streamed strings.Builder
+	lastEdit := time.Now()
+	summary, err := llmService.SummarizeFromTextStream(llmCtx, textToSummarize, func(chunk string) {
+		streamed.WriteString(chunk)
+		if time.Since(lastEdit) >= streamEditInterval {
+			lastEdit = time.Now()
+			edit(streamed.String() + " ✍️")
+		}
+	})
+	if err != nil {
+		log.Printf("Error getting summary: %v", err)
+		edit("Maaf, terjadi kesalahan saat meringkas teks.")
+		return
+	}
+	edit(summary)
+}
+
+// summarizeLinkCommand reads the webpage at the given URL and summarizes it
+func summarizeLinkCommand(ctx *CommandContext) {
+	// 1. Get the URL from the message
+	url := ctx.RawArgs
+	if url == "" {
+		ctx.Reply("Tolong berikan URL yang valid.")
+		return
+	}
+	if llmService == nil {
+		ctx.Reply(llmOffMessage)
+		return
+	}
+
+	ctx.Reply("Mengakses halaman web... Mohon tunggu.")
+
+	// 2. Get the webpage content
+	pageContent, err := llmService.ReadWebPages(url)
+	if err != nil {
+		log.Printf("Error reading webpage: %v", err)
+		ctx.Reply("Maaf, gagal mengakses URL tersebut.")
+		return
+	}
+
+	// This is a good check in case the page was empty
+	if pageContent == "" {
+		ctx.Reply("Halaman web tersebut tidak memiliki konten yang bisa dibaca.")
+		return
+	}
+
+	ctx.Reply("Halaman berhasil diakses. Sekarang, saya akan meringkas isinya...")
+
+	// 3. Feed the page content into the summarizer
+	llmCtx, cancel := context.WithTimeout(context.Background(), llmTimeout)
+	defer cancel()
+
+	summary, err := llmService.SummarizeFromText(llmCtx, pageContent)
+	if err != nil {
+		log.Printf("Error from LLM service on webpage content: %v", err)
+		ctx.Reply("Maaf, terjadi kesalahan saat meringkas konten halaman web.")
+		return
+	}
+
+	// 4. Send the final summary to the user
+	ctx.Reply("**Berikut ringkasan dari halaman web:**\n" + summary)
+}
@@ -0,0 +1,407 @@
+package bot
+
+import (
+	todo_utils "Discord_bot_v1/todo-utils"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func init() {
+	commandRouter.Register(&Command{
+		Name:        "todo-create",
+		Description: "Create a new task",
+		Category:    "Task Management",
+		Handler:     todoCreateCommand,
+	})
+	commandRouter.Register(&Command{
+		Name:        "todo-list",
+		Usage:       "[status:<status>] [priority:<priority>] [#<tag>] [sort:<due|priority|created|title>]",
+		Description: "View your tasks (with pagination), optionally filtered and sorted",
+		Category:    "Task Management",
+		Parse:       parseTaskQueryArgs,
+		Handler:     todoListCommand,
+	})
+	commandRouter.Register(&Command{
+		Name:        "todo-tags",
+		Description: "List the tags on your tasks, with how many tasks have each",
+		Category:    "Task Management",
+		Handler:     todoTagsCommand,
+	})
+	commandRouter.Register(&Command{
+		Name:        "todo-search",
+		Usage:       "<query>",
+		Description: "Search your tasks by title, tag and description, typos are fine",
+		Category:    "Task Management",
+		Parse:       parseSearchQuery,
+		Handler:     todoSearchCommand,
+	})
+	commandRouter.Register(&Command{
+		Name:        "todo-update",
+		Usage:       "<number|T-n|\"title\"|id>",
+		Description: "Update a task by its number from !todo-list, its handle like T-3, a \"piece of its title\" or its ID",
+		Category:    "Task Management",
+		Parse:       parseTaskRef,
+		Handler:     todoUpdateCommand,
+	})
+	commandRouter.Register(&Command{
+		Name:        "todo-delete",
+		Usage:       "<number|T-n|\"title\"|id> or <1,3,5-8>",
+		Description: "Delete a task by its number from !todo-list, its handle like T-3, a \"piece of its title\" or its ID, or several at once like 1,3,5-8",
+		Category:    "Task Management",
+		Parse:       parseTaskSelection,
+		Handler:     todoDeleteCommand,
+	})
+	commandRouter.Register(&Command{
+		Name:        "todo-done",
+		Usage:       "<numbers|T-n|\"title\">",
+		Description: "Mark one or more tasks as done, e.g. !todo-done 2 4 6 or !todo-done 1-3",
+		Category:    "Task Management",
+		Parse:       parseTaskSelection,
+		Handler:     todoDoneCommand,
+	})
+	commandRouter.Register(&Command{
+		Name:        "todo-clear",
+		Usage:       "<status:<status>|priority:<priority>|#<tag>>",
+		Description: "Delete every task matching a filter, e.g. !todo-clear status:done",
+		Category:    "Task Management",
+		Parse:       parseClearArgs,
+		Handler:     todoClearCommand,
+	})
+	commandRouter.Register(&Command{
+		Name:        "todo-undo",
+		Description: "Undo your latest task update or deletion",
+		Category:    "Task Management",
+		Handler:     todoUndoCommand,
+	})
+	commandRouter.Register(&Command{
+		Name:        "todo-timezone",
+		Usage:       "[timezone]",
+		Description: "Show or set the timezone your due dates are read in, e.g. Asia/Jakarta",
+		Category:    "Task Management",
+		Parse:       parseTimezone,
+		Handler:     todoTimezoneCommand,
+	})
+	commandRouter.Register(&Command{
+		Name:        "todo-digest",
+		Usage:       "[on|off|now] [HH:MM] [timezone] [focus|nofocus]",
+		Description: "Get a morning DM with your open tasks, e.g. !todo-digest on 07:30 Asia/Jakarta focus",
+		Category:    "Task Management",
+		Parse:       parseDigestArgs,
+		Handler:     todoDigestCommand,
+	})
+}
+
+// digestArgs are the parsed arguments of !todo-digest. Fields left nil are not changed.
+type digestArgs struct {
+	// Action is "on", "off", "now" or "" to just show the current settings
+	Action   string
+	Time     *string
+	Location *time.Location
+	Focus    *bool
+}
+
+// parseDigestArgs parses the !todo-digest arguments, which can come in any order.
+// Giving a time, timezone or focus option implies "on".
+func parseDigestArgs(raw string) (interface{}, error) {
+	args := digestArgs{}
+	for _, token := range strings.Fields(raw) {
+		switch lower := strings.ToLower(token); {
+		case lower == "on" || lower == "off" || lower == "now":
+			args.Action = lower
+		case lower == "focus" || lower == "nofocus":
+			focus := lower == "focus"
+			args.Focus = &focus
+		case strings.Contains(token, ":") || strings.Contains(token, "."):
+			t, err := time.Parse("15:04", strings.Replace(token, ".", ":", 1))
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a time, use HH:MM like 07:30", token)
+			}
+			hhmm := t.Format("15:04")
+			args.Time = &hhmm
+		default:
+			loc, err := parseTimezone(token)
+			if err != nil {
+				return nil, fmt.Errorf("I don't understand %q. Use on, off, now, a time like 07:30, a timezone like Asia/Jakarta, focus or nofocus", token)
+			}
+			args.Location = loc.(*time.Location)
+		}
+	}
+
+	if args.Action == "" && (args.Time != nil || args.Location != nil || args.Focus != nil) {
+		args.Action = "on"
+	}
+	return args, nil
+}
+
+// parseTimezone parses the optional IANA timezone argument.
+// No argument yields nil so the handler shows the current one instead.
+func parseTimezone(raw string) (interface{}, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	loc, err := time.LoadLocation(raw)
+	if err != nil || raw == "Local" {
+		return nil, fmt.Errorf("%q is not a timezone I know, try something like Asia/Jakarta or Europe/London", raw)
+	}
+	return loc, nil
+}
+
+// parseSearchQuery requires something to search for
+func parseSearchQuery(raw string) (interface{}, error) {
+	if raw == "" {
+		return nil, errors.New("please tell me what to search for")
+	}
+	return raw, nil
+}
+
+// sendTaskList sends the user's tasks matching the query to the given channel.
+// The list opens on the page the user was last on if the query didn't change, else on page 1.
+func sendTaskList(ctx context.Context, s *discordgo.Session, userID string, channelID string, query todo_utils.TaskQuery) {
+	page := 1
+	if query == currentQuery(userID) {
+		page = currentPage(userID)
+	}
+
+	message, actions, err := renderTaskList(ctx, userID, page, query)
+	if err != nil {
+		s.ChannelMessageSend(channelID, todoErrorMessage(err))
+		return
+	}
+
+	// Send message with navigation buttons
+	if len(actions) > 0 {
+		s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+			Content:    message,
+			Components: actions,
+		})
+	} else {
+		s.ChannelMessageSend(channelID, message)
+	}
+}
+
+// resolveTaskNumber looks up the task ID behind a friendly number from the user's last
+// !todo-list, telling the user what went wrong if it can't
+func resolveTaskNumber(ctx *CommandContext, number int) (string, bool) {
+	paginationState, exists := userPagination.Get(ctx.Message.Author.ID)
+	if !exists || paginationState.TaskIDMap == nil {
+		ctx.SendDM(fmt.Sprintf("❌ Error: Task list not found. Please run `%stodo-list` first, or refer to the task by \"title\".", commandRouter.Prefix))
+		return "", false
+	}
+
+	taskID, ok := lookupTaskID(ctx.Message.Author.ID, number)
+	if !ok {
+		ctx.SendDM(fmt.Sprintf("❌ Invalid task number. Please run `%stodo-list` to see the current task numbers.", commandRouter.Prefix))
+		return "", false
+	}
+	return taskID, true
+}
+
+// todoCreateCommand starts the create wizard in DMs
+func todoCreateCommand(ctx *CommandContext) {
+	ctx.NotifyDM("to create a new task")
+
+	startWizard(ctx.Session, ctx.Message.Author.ID, ctx.DM(), "create", nil)
+}
+
+// todoListCommand shows the user's tasks in DMs, filtered and sorted as asked
+func todoListCommand(ctx *CommandContext) {
+	ctx.NotifyDM("for your task list")
+	sendTaskList(ctx.Ctx, ctx.Session, ctx.Message.Author.ID, ctx.DM(), ctx.Args.(todo_utils.TaskQuery))
+}
+
+// todoTagsCommand lists the user's tags with their task counts in DMs
+func todoTagsCommand(ctx *CommandContext) {
+	ctx.NotifyDM("for your tags")
+
+	message, err := renderTagList(ctx.Ctx, ctx.Message.Author.ID)
+	if err != nil {
+		ctx.SendDM(todoErrorMessage(err))
+		return
+	}
+	ctx.SendDM(message)
+}
+
+// todoSearchCommand searches the user's tasks and shows the results numbered in DMs
+func todoSearchCommand(ctx *CommandContext) {
+	ctx.NotifyDM("for your search results")
+
+	message, err := renderSearchResults(ctx.Ctx, ctx.Message.Author.ID, ctx.Args.(string))
+	if err != nil {
+		ctx.SendDM(todoErrorMessage(err))
+		return
+	}
+	ctx.SendDM(message)
+}
+
+// todoUpdateCommand starts the update wizard for the given task
+func todoUpdateCommand(ctx *CommandContext) {
+	ctx.NotifyDM("to update a task")
+
+	if ctx.Args == nil {
+		// No task provided, show the task list automatically
+		ctx.SendDM("No task provided. Here's your task list:")
+		sendTaskList(ctx.Ctx, ctx.Session, ctx.Message.Author.ID, ctx.DM(), currentQuery(ctx.Message.Author.ID))
+		return
+	}
+
+	startTaskWizard(ctx, "update")
+}
+
+// todoDeleteCommand starts the delete confirmation wizard for the given task
+func todoDeleteCommand(ctx *CommandContext) {
+	ctx.NotifyDM("to delete a task")
+
+	if ctx.Args == nil {
+		// No task provided, show the task list automatically
+		ctx.SendDM("No task provided. Here's your task list:")
+		sendTaskList(ctx.Ctx, ctx.Session, ctx.Message.Author.ID, ctx.DM(), currentQuery(ctx.Message.Author.ID))
+		return
+	}
+
+	if refs, ok := ctx.Args.([]taskRef); ok {
+		if tasks, ok := resolveSelection(ctx, refs); ok {
+			confirmBulk(ctx, bulkActionDelete, tasks)
+		}
+		return
+	}
+	startTaskWizard(ctx, "delete")
+}
+
+// todoDoneCommand marks the given tasks as done after a single confirmation
+func todoDoneCommand(ctx *CommandContext) {
+	ctx.NotifyDM("to mark tasks as done")
+
+	var refs []taskRef
+	switch args := ctx.Args.(type) {
+	case taskRef:
+		refs = []taskRef{args}
+	case []taskRef:
+		refs = args
+	default:
+		ctx.SendDM(fmt.Sprintf("Tell me which tasks are done, e.g. `%stodo-done 2 4 6`. Here's your task list:", commandRouter.Prefix))
+		sendTaskList(ctx.Ctx, ctx.Session, ctx.Message.Author.ID, ctx.DM(), currentQuery(ctx.Message.Author.ID))
+		return
+	}
+
+	if tasks, ok := resolveSelection(ctx, refs); ok {
+		confirmBulk(ctx, bulkActionDone, tasks)
+	}
+}
+
+// todoClearCommand deletes every task matching the filter after a single confirmation
+func todoClearCommand(ctx *CommandContext) {
+	ctx.NotifyDM("to clear tasks")
+
+	query := ctx.Args.(todo_utils.TaskQuery)
+	tasks, err := fetchAllTasks(ctx.Ctx, ctx.Message.Author.ID)
+	if err != nil {
+		ctx.SendDM(todoErrorMessage(err))
+		return
+	}
+
+	var matching []todo_utils.Task
+	for _, task := range tasks {
+		if query.Matches(&task) {
+			matching = append(matching, task)
+		}
+	}
+	sortByDueDate(matching)
+	confirmBulk(ctx, bulkActionDelete, matching)
+}
+
+// todoUndoCommand reverts the user's latest update or deletion and shows the restored task in DMs
+func todoUndoCommand(ctx *CommandContext) {
+	ctx.NotifyDM("for the restored task")
+
+	tasks, undone, err := undoChange(ctx.Ctx, ctx.Message.Author.ID, "")
+	if err != nil {
+		ctx.SendDM(undoErrorMessage(err))
+		return
+	}
+	ctx.Session.ChannelMessageSendEmbed(ctx.DM(), undoEmbed(undone, tasks))
+}
+
+// todoTimezoneCommand shows or changes the user's timezone
+func todoTimezoneCommand(ctx *CommandContext) {
+	userID := ctx.Message.Author.ID
+
+	if ctx.Args == nil {
+		loc := userLocation(userID)
+		ctx.Reply(fmt.Sprintf("🕒 Your due dates are read in **%s** (it's %s there now). Use `%stodo-timezone <timezone>` to change it.",
+			loc, time.Now().In(loc).Format("15:04"), commandRouter.Prefix))
+		return
+	}
+
+	loc := ctx.Args.(*time.Location)
+	updateUserSettings(userID, func(settings *UserSettings) {
+		settings.Timezone = loc.String()
+	})
+	ctx.Reply(fmt.Sprintf("✅ Timezone set to **%s** (it's %s there now).", loc, time.Now().In(loc).Format("15:04")))
+}
+
+// todoDigestCommand subscribes to, changes or cancels the daily digest
+func todoDigestCommand(ctx *CommandContext) {
+	userID := ctx.Message.Author.ID
+	args := ctx.Args.(digestArgs)
+
+	switch args.Action {
+	case "off":
+		updateUserSettings(userID, func(settings *UserSettings) {
+			settings.Digest.Enabled = false
+		})
+		ctx.Reply("🔕 Daily digest turned off.")
+		return
+
+	case "now":
+		ctx.NotifyDM("for your digest")
+		embed, err := buildDigest(ctx.Ctx, userID, time.Now(), getUserSettings(userID).Digest.Focus)
+		if err != nil {
+			ctx.SendDM(todoErrorMessage(err))
+			return
+		}
+		ctx.Session.ChannelMessageSendEmbed(ctx.DM(), embed)
+		return
+
+	case "on":
+		now := time.Now()
+		updateUserSettings(userID, func(settings *UserSettings) {
+			settings.Digest.Enabled = true
+			if args.Time != nil {
+				settings.Digest.Time = *args.Time
+			}
+			if settings.Digest.Time == "" {
+				settings.Digest.Time = defaultDigestTime
+			}
+			if args.Location != nil {
+				settings.Timezone = args.Location.String()
+			}
+			if args.Focus != nil {
+				settings.Digest.Focus = *args.Focus
+			}
+
+			// Today's time already passed, start tomorrow rather than right away
+			local := now.In(settings.Location())
+			if !local.Before(digestTimeOn(local, settings.Digest.Time)) {
+				settings.Digest.LastSent = local.Format(time.DateOnly)
+			}
+		})
+	}
+
+	settings := getUserSettings(userID)
+	if !settings.Digest.Enabled {
+		ctx.Reply(fmt.Sprintf("🔕 Your daily digest is off. Turn it on with `%stodo-digest on 07:00`, optionally followed by your timezone and `focus`.", commandRouter.Prefix))
+		return
+	}
+
+	focus := "without"
+	if settings.Digest.Focus {
+		focus = "with"
+	}
+	ctx.Reply(fmt.Sprintf("🔔 Your daily digest arrives at **%s** (%s), %s a focus for today. Use `%stodo-digest now` for a preview or `%stodo-digest off` to stop it.",
+		settings.Digest.Time, userLocation(userID), focus, commandRouter.Prefix, commandRouter.Prefix))
+}
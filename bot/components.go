@@ -0,0 +1,371 @@
+package bot
+
+import (
+	todo_utils "Discord_bot_v1/todo-utils"
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Modal text input IDs
+const (
+	modalTitleInput       = "title"
+	modalStatusInput      = "status"
+	modalDescriptionInput = "description"
+	modalDueDateInput     = "due_date"
+	modalPriorityInput    = "priority"
+)
+
+// handleTodoComponent handles the buttons on the todo list message.
+// Custom IDs look like todo_<action>_<argument>, e.g. todo_next_2, todo_next_2_status:done, todo_edit_<taskID> or todo_pick_delete.
+func handleTodoComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	customID := i.MessageComponentData().CustomID
+	parts := strings.SplitN(customID, "_", 3)
+	if len(parts) < 2 || parts[0] != "todo" {
+		return
+	}
+
+	// If we can't identify the user, return
+	userID := interactionUserID(i)
+	if userID == "" {
+		return
+	}
+
+	// Buttons that open a form must answer with it, so only the others are deferred
+	timeout := interactionTimeout
+	if parts[1] != "new" && parts[1] != "edit" {
+		defer deferInteraction(s, i)()
+		timeout = commandTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	switch parts[1] {
+	case "prev", "next":
+		if len(parts) != 3 {
+			return
+		}
+		pageArg, queryArg, _ := strings.Cut(parts[2], "_")
+		page, err := strconv.Atoi(pageArg)
+		if err != nil {
+			return
+		}
+		query, err := parseTaskQuery(queryArg)
+		if err != nil {
+			return
+		}
+		respondWithTaskList(ctx, s, i, userID, page, query, "", "")
+
+	case "new":
+		openTaskModal(s, i, "todo_create", "➕ New task", nil)
+
+	case "edit":
+		if len(parts) != 3 {
+			return
+		}
+		task, err := TodoApp.GetTask(ctx, parts[2], userID)
+		if err != nil {
+			respondEphemeral(s, i, todoErrorMessage(err))
+			return
+		}
+		openTaskModal(s, i, "todo_update_"+task.ID, "✏️ Edit task", task)
+
+	case "action":
+		values := i.MessageComponentData().Values
+		if len(values) != 1 {
+			return
+		}
+		action, taskID, ok := strings.Cut(values[0], "_")
+		if !ok {
+			return
+		}
+		runTaskAction(ctx, s, i, userID, action, taskID)
+
+	case "pick":
+		values := i.MessageComponentData().Values
+		if len(parts) != 3 || len(values) != 1 {
+			return
+		}
+		pickTask(ctx, s, i, userID, parts[2], values[0])
+	}
+}
+
+// runTaskAction applies a quick action picked from the todo list menu,
+// then re-renders the list in place
+func runTaskAction(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, userID string, action string, taskID string) {
+	task, err := TodoApp.GetTask(ctx, taskID, userID)
+	if err != nil {
+		respondEphemeral(s, i, todoErrorMessage(err))
+		return
+	}
+
+	var notice, undoID string
+	switch action {
+	case taskActionDone, taskActionStart, taskActionReopen:
+		status := map[string]string{
+			taskActionDone:   "done",
+			taskActionStart:  "in-progress",
+			taskActionReopen: "backlog",
+		}[action]
+
+		input := task.Input()
+		input.Status = status
+		if _, undoID, err = updateTaskWithUndo(ctx, userID, task, input); err != nil {
+			respondEphemeral(s, i, todoErrorMessage(err))
+			return
+		}
+		notice = fmt.Sprintf("%s **%s** is now %s", statusEmoji(status), task.Title, status)
+
+	case taskActionDelete:
+		if undoID, err = deleteTaskWithUndo(ctx, userID, task); err != nil {
+			respondEphemeral(s, i, todoErrorMessage(err))
+			return
+		}
+		notice = fmt.Sprintf("🗑️ Deleted **%s**", task.Title)
+
+	default:
+		return
+	}
+
+	page := currentPage(userID)
+	respondWithTaskList(ctx, s, i, userID, page, currentQuery(userID), notice, undoID)
+}
+
+// handleTodoModal handles the submitted task modals.
+// Custom IDs are todo_create, todo_create_draft_<draftID> or todo_update_<taskID>.
+func handleTodoModal(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ModalSubmitData()
+	parts := strings.SplitN(data.CustomID, "_", 3)
+	if len(parts) < 2 || parts[0] != "todo" {
+		return
+	}
+
+	userID := interactionUserID(i)
+	if userID == "" {
+		return
+	}
+
+	defer deferInteraction(s, i)()
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	values := modalValues(data)
+	title := strings.TrimSpace(values[modalTitleInput])
+	description := strings.TrimSpace(values[modalDescriptionInput])
+	status, err := validateStatus(strings.TrimSpace(values[modalStatusInput]))
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("❌ %v", err))
+		return
+	}
+
+	// The modal is a full form, so an empty due date means none
+	dueDate := ""
+	if due := strings.TrimSpace(values[modalDueDateInput]); due != "" {
+		dueDate, err = parseDueDate(userID, due)
+		if err != nil {
+			respondEphemeral(s, i, fmt.Sprintf("❌ %v", err))
+			return
+		}
+		if dueDate == dueDateNone {
+			dueDate = ""
+		}
+	}
+
+	// Priority is optional in the form, empty means the default
+	priority := todo_utils.DefaultPriority
+	if value := strings.TrimSpace(values[modalPriorityInput]); value != "" {
+		priority, err = validatePriority(value)
+		if err != nil {
+			respondEphemeral(s, i, fmt.Sprintf("❌ %v", err))
+			return
+		}
+	}
+	input := todo_utils.TaskInput{Title: title, Status: status, Description: description, DueDate: dueDate, Priority: priority}
+
+	// Re-render the list the modal was opened from, with the result on top
+	page := currentPage(userID)
+
+	switch parts[1] {
+	case "create":
+		// Opened from a !todo add card, which shows the result instead of the list
+		var draft Draft
+		fromDraft := false
+		if len(parts) == 3 {
+			draftID, ok := strings.CutPrefix(parts[2], "draft_")
+			if !ok {
+				return
+			}
+			if draft, fromDraft = takeDraft(userID, draftID); !fromDraft {
+				updateDraftCard(s, i, draftGoneMessage(), nil)
+				return
+			}
+		}
+
+		task, err := TodoApp.CreateTask(ctx, input, userID)
+		if err != nil {
+			if fromDraft {
+				restoreDraft(userID, draft)
+			}
+			respondEphemeral(s, i, todoErrorMessage(err))
+			return
+		}
+		if fromDraft {
+			updateDraftCard(s, i, "", taskEmbed("✅ Task Created", task, embedColorCreated))
+			return
+		}
+		respondWithTaskList(ctx, s, i, userID, page, currentQuery(userID), fmt.Sprintf("✅ Task Created: %s", title), "")
+
+	case "update":
+		if len(parts) != 3 {
+			return
+		}
+		// Fetch the current task first so the change can be undone
+		before, err := TodoApp.GetTask(ctx, parts[2], userID)
+		if err != nil {
+			respondEphemeral(s, i, todoErrorMessage(err))
+			return
+		}
+		_, undoID, err := updateTaskWithUndo(ctx, userID, before, input)
+		if err != nil {
+			respondEphemeral(s, i, todoErrorMessage(err))
+			return
+		}
+		respondWithTaskList(ctx, s, i, userID, page, currentQuery(userID), fmt.Sprintf("✅ Task Updated: %s", title), undoID)
+	}
+}
+
+// respondWithTaskList re-renders the todo list message in place.
+// notice, if not empty, is shown above the list, and undoID adds an Undo button for that change.
+func respondWithTaskList(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, userID string, page int, query todo_utils.TaskQuery, notice string, undoID string) {
+	message, actions, err := renderTaskList(ctx, userID, page, query)
+	if err != nil {
+		respondEphemeral(s, i, todoErrorMessage(err))
+		return
+	}
+	if notice != "" {
+		message = notice + "\n\n" + message
+	}
+	if undoID != "" {
+		// Next to the navigation buttons, the first row always has room
+		row := actions[0].(discordgo.ActionsRow)
+		row.Components = append(row.Components, undoButton(undoID, true))
+		actions[0] = row
+	}
+
+	// Respond to the interaction with updated message
+	err = updateMessage(s, i, &discordgo.InteractionResponseData{
+		Content:    message,
+		Components: actions,
+	})
+	if err != nil {
+		log.Printf("Failed to update task list: %v", err)
+	}
+}
+
+// openTaskModal shows the task form. If task is not nil, the fields are pre-filled with its values.
+func openTaskModal(s *discordgo.Session, i *discordgo.InteractionCreate, customID string, title string, task *todo_utils.Task) {
+	var current todo_utils.Task
+	if task != nil {
+		current = *task
+	}
+
+	// Tags go back into the title as #tags, so they can be edited there
+	currentTitle := current.Title
+	if len(current.Tags) > 0 {
+		currentTitle += " #" + strings.Join(current.Tags, " #")
+	}
+
+	// Due dates are pre-filled in a format parseDueDate reads back as is
+	currentDue := ""
+	if due, ok := current.Due(); ok {
+		currentDue = due.In(userLocation(interactionUserID(i))).Format("2006-01-02 15:04")
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: customID,
+			Title:    title,
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:    modalTitleInput,
+						Label:       "Title",
+						Style:       discordgo.TextInputShort,
+						Placeholder: "e.g. Fix the login page #frontend",
+						Value:       currentTitle,
+						Required:    true,
+						MaxLength:   200,
+					},
+				}},
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:    modalStatusInput,
+						Label:       "Status",
+						Style:       discordgo.TextInputShort,
+						Placeholder: strings.Join(taskStatuses, ", "),
+						Value:       current.Status,
+						Required:    true,
+						MaxLength:   20,
+					},
+				}},
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:  modalDescriptionInput,
+						Label:     "Description",
+						Style:     discordgo.TextInputParagraph,
+						Value:     current.Description,
+						Required:  false,
+						MaxLength: 1000,
+					},
+				}},
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:    modalDueDateInput,
+						Label:       "Due date",
+						Style:       discordgo.TextInputShort,
+						Placeholder: "e.g. 2026-10-20, tomorrow 9am, besok, next friday",
+						Value:       currentDue,
+						Required:    false,
+						MaxLength:   50,
+					},
+				}},
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:    modalPriorityInput,
+						Label:       "Priority",
+						Style:       discordgo.TextInputShort,
+						Placeholder: strings.Join(todo_utils.Priorities, ", "),
+						Value:       current.Priority,
+						Required:    false,
+						MaxLength:   10,
+					},
+				}},
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to open task modal: %v", err)
+	}
+}
+
+// modalValues collects the text input values of a submitted modal by custom ID
+func modalValues(data discordgo.ModalSubmitInteractionData) map[string]string {
+	values := make(map[string]string)
+	for _, row := range data.Components {
+		actionsRow, ok := row.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, component := range actionsRow.Components {
+			if input, ok := component.(*discordgo.TextInput); ok {
+				values[input.CustomID] = input.Value
+			}
+		}
+	}
+	return values
+}
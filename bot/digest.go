@@ -0,0 +1,253 @@
+package bot
+
+import (
+	todo_utils "Discord_bot_v1/todo-utils"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// defaultDigestTime is when digests are sent unless the user picks another time
+const defaultDigestTime = "07:00"
+
+// digestCheckInterval is how often we look for digests that are due
+const digestCheckInterval = time.Minute
+
+// digestSectionLimit is how many tasks each digest section lists at most
+const digestSectionLimit = 10
+
+// embedColorDigest is the color of the daily digest embed
+const embedColorDigest = 0xf1c40f
+
+// runDigests sends every daily digest that is due, once a minute, until stop is closed
+func runDigests(s *discordgo.Session, stop <-chan struct{}) {
+	ticker := time.NewTicker(digestCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			checkDigests(s, now)
+		}
+	}
+}
+
+// checkDigests sends today's digest to every subscriber whose delivery time has passed.
+// The day is recorded before sending, so a digest is never sent twice in one day.
+func checkDigests(s *discordgo.Session, now time.Time) {
+	userSettings.Each(func(userID string, settings UserSettings) {
+		if !settings.Digest.Enabled {
+			return
+		}
+
+		local := now.In(userLocation(userID))
+		today := local.Format(time.DateOnly)
+		if settings.Digest.LastSent == today || local.Before(digestTimeOn(local, settings.Digest.Time)) {
+			return
+		}
+
+		updateUserSettings(userID, func(settings *UserSettings) {
+			settings.Digest.LastSent = today
+		})
+		go sendDigest(s, userID, now)
+	})
+}
+
+// digestTimeOn returns the delivery time on the day of local
+func digestTimeOn(local time.Time, hhmm string) time.Time {
+	t, err := time.Parse("15:04", hhmm)
+	if err != nil {
+		t, _ = time.Parse("15:04", defaultDigestTime)
+	}
+	return time.Date(local.Year(), local.Month(), local.Day(), t.Hour(), t.Minute(), 0, 0, local.Location())
+}
+
+// sendDigest builds the user's digest and sends it in DM
+func sendDigest(s *discordgo.Session, userID string, now time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	embed, err := buildDigest(ctx, userID, now, getUserSettings(userID).Digest.Focus)
+	if err != nil {
+		log.Printf("Failed to build digest for user %s: %v", userID, err)
+		return
+	}
+
+	dmChannel, err := s.UserChannelCreate(userID)
+	if err != nil {
+		log.Printf("Failed to create DM channel for user %s: %v", userID, err)
+		return
+	}
+	if _, err := s.ChannelMessageSendEmbed(dmChannel.ID, embed); err != nil {
+		log.Printf("Failed to send digest to user %s: %v", userID, err)
+	}
+}
+
+// digest groups the user's tasks into the digest sections
+type digest struct {
+	overdue            []todo_utils.Task
+	dueToday           []todo_utils.Task
+	inProgress         []todo_utils.Task
+	backlog            []todo_utils.Task
+	completedYesterday []todo_utils.Task
+}
+
+// groupDigest sorts tasks into the digest sections. Open tasks with a due date up to
+// today are only listed under overdue or due today, not under their status too.
+func groupDigest(tasks []todo_utils.Task, now time.Time, loc *time.Location) digest {
+	local := now.In(loc)
+	startOfToday := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	startOfTomorrow := startOfToday.AddDate(0, 0, 1)
+	startOfYesterday := startOfToday.AddDate(0, 0, -1)
+
+	var d digest
+	for _, task := range tasks {
+		if task.Status == "done" {
+			// The backend doesn't record when a task was completed, its last update is the best guess
+			updated, err := time.Parse(time.RFC3339, task.UpdatedAt)
+			if err == nil && !updated.Before(startOfYesterday) && updated.Before(startOfToday) {
+				d.completedYesterday = append(d.completedYesterday, task)
+			}
+			continue
+		}
+
+		if due, ok := task.Due(); ok && due.Before(startOfTomorrow) {
+			if due.Before(startOfToday) {
+				d.overdue = append(d.overdue, task)
+			} else {
+				d.dueToday = append(d.dueToday, task)
+			}
+			continue
+		}
+
+		switch task.Status {
+		case "in-progress":
+			d.inProgress = append(d.inProgress, task)
+		case "backlog":
+			d.backlog = append(d.backlog, task)
+		}
+	}
+
+	sortByDueDate(d.overdue)
+	sortByDueDate(d.dueToday)
+	sortByDueDate(d.inProgress)
+	sortByDueDate(d.backlog)
+	return d
+}
+
+// buildDigest fetches every task of the user and builds the digest embed.
+// With focus set, an LLM written "focus for today" is added; if that fails the digest goes out without it.
+func buildDigest(ctx context.Context, userID string, now time.Time, focus bool) (*discordgo.MessageEmbed, error) {
+	tasks, err := fetchAllTasks(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := userLocation(userID)
+	d := groupDigest(tasks, now, loc)
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "☀️ Your daily digest",
+		Description: now.In(loc).Format("Monday, 02 January 2006"),
+		Color:       embedColorDigest,
+	}
+
+	sections := []struct {
+		name  string
+		tasks []todo_utils.Task
+	}{
+		{"⚠️ Overdue", d.overdue},
+		{"📅 Due today", d.dueToday},
+		{"🔄 In progress", d.inProgress},
+		{"📥 Backlog", d.backlog},
+		{"✅ Completed yesterday", d.completedYesterday},
+	}
+	for _, section := range sections {
+		if len(section.tasks) == 0 {
+			continue
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("%s (%d)", section.name, len(section.tasks)),
+			Value: digestLines(userID, section.tasks),
+		})
+	}
+
+	open := len(d.overdue) + len(d.dueToday) + len(d.inProgress) + len(d.backlog)
+	if open == 0 {
+		embed.Description += "\n\n🎉 Nothing open, enjoy your day!"
+		return embed, nil
+	}
+
+	if focus && llmService != nil {
+		llmCtx, cancel := context.WithTimeout(context.Background(), llmTimeout)
+		paragraph, err := llmService.FocusForToday(llmCtx, digestOverview(d))
+		cancel()
+		if err != nil {
+			log.Printf("Failed to get focus for today for user %s: %v", userID, err)
+		} else if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+				Name:  "🎯 Focus for today",
+				Value: truncate(paragraph, 1024),
+			})
+		}
+	}
+
+	return embed, nil
+}
+
+// digestLines lists tasks for an embed field, up to digestSectionLimit of them
+func digestLines(userID string, tasks []todo_utils.Task) string {
+	lines := []string{}
+	for i, task := range tasks {
+		if i == digestSectionLimit {
+			lines = append(lines, fmt.Sprintf("…and %d more", len(tasks)-digestSectionLimit))
+			break
+		}
+		line := "• " + handleLabel(userID, &task) + " " + truncate(task.Title, 80)
+		if due, ok := task.Due(); ok {
+			line += " · 📅 " + formatDueDate(userID, due)
+		}
+		lines = append(lines, line)
+	}
+	// Embed field values are capped at 1024 characters
+	return truncate(strings.Join(lines, "\n"), 1024)
+}
+
+// digestOverview is the plain text task overview given to the LLM
+func digestOverview(d digest) string {
+	var b strings.Builder
+	write := func(heading string, tasks []todo_utils.Task) {
+		if len(tasks) == 0 {
+			return
+		}
+		b.WriteString(heading + ":\n")
+		for _, task := range tasks {
+			b.WriteString("- " + task.Title)
+			if task.Description != "" {
+				b.WriteString(" (" + truncate(task.Description, 200) + ")")
+			}
+			if task.DueDate != "" {
+				b.WriteString(" [due " + task.DueDate + "]")
+			}
+			if priority := task.EffectivePriority(); priority != todo_utils.DefaultPriority {
+				b.WriteString(" [priority " + priority + "]")
+			}
+			if len(task.Tags) > 0 {
+				b.WriteString(" [tags " + strings.Join(task.Tags, ", ") + "]")
+			}
+			b.WriteString("\n")
+		}
+	}
+	write("Overdue", d.overdue)
+	write("Due today", d.dueToday)
+	write("In progress", d.inProgress)
+	write("Backlog", d.backlog)
+	write("Completed yesterday", d.completedYesterday)
+	return b.String()
+}
@@ -0,0 +1,246 @@
+package bot
+
+import (
+	"Discord_bot_v1/llm_utils"
+	todo_utils "Discord_bot_v1/todo-utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// draftTTL is how long a task read from a sentence waits to be confirmed
+const draftTTL = 15 * time.Minute
+
+// embedColorDraft is the color of the card asking to confirm a draft
+const embedColorDraft = 0xf1c40f
+
+// Draft is a task read from a sentence, waiting for the user to confirm it
+type Draft struct {
+	// ID ties the buttons of a draft card to the draft it shows
+	ID   string
+	Task todo_utils.Task
+}
+
+// userDrafts stores the task each user is about to create with !todo add, not yet confirmed.
+// A user has at most one draft, a new !todo add replaces it.
+var userDrafts StateStore[Draft]
+
+// draftsMu serialises taking drafts out of userDrafts
+var draftsMu sync.Mutex
+
+func init() {
+	commandRouter.Register(&Command{
+		Name:        "todo",
+		Usage:       "add <task in your own words>",
+		Description: "Create a task from a sentence, e.g. !todo add send the invoice to Budi next Tuesday, high priority",
+		Category:    "Task Management",
+		Parse:       parseTodoAdd,
+		Handler:     todoAddCommand,
+	})
+}
+
+// parseTodoAdd takes the sentence after "add"
+func parseTodoAdd(raw string) (interface{}, error) {
+	subcommand, text, _ := strings.Cut(raw, " ")
+	if !strings.EqualFold(subcommand, "add") {
+		return nil, errors.New("tell me what to add")
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, errors.New("tell me what to add")
+	}
+	return text, nil
+}
+
+// todoAddCommand reads a task from a sentence with the LLM and asks the user to confirm it.
+// When the LLM isn't available or the sentence is unclear, the create wizard takes over.
+func todoAddCommand(ctx *CommandContext) {
+	ctx.NotifyDM("for your new task")
+	userID := ctx.Message.Author.ID
+	text := ctx.Args.(string)
+
+	fallback := func(reason string) {
+		ctx.SendDM(reason + " Let's go through it step by step instead.")
+		startWizard(ctx.Session, userID, ctx.DM(), "create", nil)
+	}
+
+	if llmService == nil {
+		fallback("🤖 I can't read tasks from sentences right now.")
+		return
+	}
+
+	ctx.Session.ChannelTyping(ctx.DM())
+	llmCtx, cancel := context.WithTimeout(context.Background(), llmTimeout)
+	defer cancel()
+	parsed, err := llmService.ParseTask(llmCtx, text, time.Now().In(userLocation(userID)))
+	if err != nil {
+		log.Printf("Error reading task from %q: %v", text, err)
+		fallback("🤖 Something went wrong reading that task.")
+		return
+	}
+
+	task, ok := draftTask(userID, parsed)
+	if !ok {
+		fallback("🤔 I couldn't quite work out that task.")
+		return
+	}
+
+	draft := Draft{ID: strconv.FormatInt(time.Now().UnixNano(), 36), Task: task}
+	userDrafts.Set(userID, draft, draftTTL)
+	ctx.Session.ChannelMessageSendComplex(ctx.DM(), &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{draftEmbed(&draft.Task)},
+		Components: draftComponents(draft.ID),
+	})
+}
+
+// draftTask checks what the LLM read and fills in the defaults. It reports false when
+// the result is too unclear to offer, so the user is better off with the wizard.
+func draftTask(userID string, parsed *llm_utils.TaskDraft) (todo_utils.Task, bool) {
+	if parsed.Ambiguous || parsed.Title == "" {
+		return todo_utils.Task{}, false
+	}
+
+	draft := todo_utils.Task{Title: truncate(parsed.Title, 200), Status: taskStatuses[0], Priority: todo_utils.DefaultPriority}
+	if status, err := validateStatus(parsed.Status); err == nil {
+		draft.Status = status
+	}
+	if priority, err := validatePriority(parsed.Priority); err == nil {
+		draft.Priority = priority
+	}
+	if parsed.Due != "" {
+		due, err := parseDueDate(userID, parsed.Due)
+		if err != nil {
+			return todo_utils.Task{}, false
+		}
+		if due != dueDateNone {
+			draft.DueDate = due
+		}
+	}
+	for _, raw := range parsed.Tags {
+		if tag, ok := todo_utils.NormalizeTag(raw); ok {
+			draft.Tags = todo_utils.MergeTags(draft.Tags, []string{tag})
+		}
+	}
+	return draft, true
+}
+
+// draftEmbed is the card asking the user to confirm a draft
+func draftEmbed(draft *todo_utils.Task) *discordgo.MessageEmbed {
+	embed := taskEmbed("📝 New task, does this look right?", draft, embedColorDraft)
+	embed.Footer = &discordgo.MessageEmbedFooter{Text: "Create it as is, edit it first, or cancel."}
+	return embed
+}
+
+// draftComponents are the buttons on a draft card. Custom IDs are draft_<create|edit|cancel>_<draftID>.
+func draftComponents(draftID string) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "✅ Create", Style: discordgo.SuccessButton, CustomID: "draft_create_" + draftID},
+			discordgo.Button{Label: "✏️ Edit", Style: discordgo.PrimaryButton, CustomID: "draft_edit_" + draftID},
+			discordgo.Button{Label: "❌ Cancel", Style: discordgo.SecondaryButton, CustomID: "draft_cancel_" + draftID},
+		}},
+	}
+}
+
+// takeDraft removes the user's draft from the store and returns it, if it is the one
+// with the given ID. Whoever takes it creates it, so a double click can't create it twice.
+func takeDraft(userID string, draftID string) (Draft, bool) {
+	draftsMu.Lock()
+	defer draftsMu.Unlock()
+
+	draft, exists := userDrafts.Get(userID)
+	if !exists || draft.ID != draftID {
+		return Draft{}, false
+	}
+	userDrafts.Delete(userID)
+	return draft, true
+}
+
+// restoreDraft puts back a draft that couldn't be created, unless a newer one took its place
+func restoreDraft(userID string, draft Draft) {
+	draftsMu.Lock()
+	defer draftsMu.Unlock()
+
+	if _, exists := userDrafts.Get(userID); !exists {
+		userDrafts.Set(userID, draft, draftTTL)
+	}
+}
+
+// draftGoneMessage tells the user a draft card's draft is no longer there
+func draftGoneMessage() string {
+	return fmt.Sprintf("⌛ This draft has expired or was replaced by a newer one. Run `%stodo add` again.", commandRouter.Prefix)
+}
+
+// handleDraftComponent handles the buttons on a draft card.
+// Edit opens the task form pre-filled with the draft, submitted as todo_create_draft_<draftID>.
+func handleDraftComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID := interactionUserID(i)
+	if userID == "" {
+		return
+	}
+
+	parts := strings.SplitN(i.MessageComponentData().CustomID, "_", 3)
+	if len(parts) != 3 {
+		return
+	}
+	action, draftID := parts[1], parts[2]
+
+	switch action {
+	case "create":
+		draft, ok := takeDraft(userID, draftID)
+		if !ok {
+			updateDraftCard(s, i, draftGoneMessage(), nil)
+			return
+		}
+
+		defer deferInteraction(s, i)()
+		ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+		defer cancel()
+
+		task, err := TodoApp.CreateTask(ctx, draft.Task.Input(), userID)
+		if err != nil {
+			restoreDraft(userID, draft)
+			respondEphemeral(s, i, todoErrorMessage(err))
+			return
+		}
+		updateDraftCard(s, i, "", taskEmbed("✅ Task Created", task, embedColorCreated))
+
+	case "edit":
+		draft, exists := userDrafts.Get(userID)
+		if !exists || draft.ID != draftID {
+			updateDraftCard(s, i, draftGoneMessage(), nil)
+			return
+		}
+		openTaskModal(s, i, "todo_create_draft_"+draft.ID, "✏️ New task", &draft.Task)
+
+	case "cancel":
+		if _, ok := takeDraft(userID, draftID); !ok {
+			updateDraftCard(s, i, draftGoneMessage(), nil)
+			return
+		}
+		updateDraftCard(s, i, "🛑 Okay, I didn't create it.", nil)
+	}
+}
+
+// updateDraftCard replaces a draft card with the outcome and removes its buttons
+func updateDraftCard(s *discordgo.Session, i *discordgo.InteractionCreate, content string, embed *discordgo.MessageEmbed) {
+	embeds := []*discordgo.MessageEmbed{}
+	if embed != nil {
+		embeds = append(embeds, embed)
+	}
+	err := updateMessage(s, i, &discordgo.InteractionResponseData{
+		Content:    content,
+		Embeds:     embeds,
+		Components: []discordgo.MessageComponent{},
+	})
+	if err != nil {
+		log.Printf("Failed to update draft card: %v", err)
+	}
+}
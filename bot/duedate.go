@@ -0,0 +1,77 @@
+package bot
+
+import (
+	"Discord_bot_v1/date_utils"
+	todo_utils "Discord_bot_v1/todo-utils"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// dueDateNone is typed to remove a task's due date
+const dueDateNone = "none"
+
+// dueDateExamples is shown whenever we ask for a due date
+const dueDateExamples = "`2026-10-20`, `tomorrow 9am`, `besok`, `next friday`"
+
+// parseDueDate reads a due date typed by the user in their timezone and returns it
+// as an RFC 3339 timestamp. dueDateNone is passed through as is.
+func parseDueDate(userID string, input string) (string, error) {
+	input = strings.TrimSpace(input)
+	if strings.EqualFold(input, dueDateNone) {
+		return dueDateNone, nil
+	}
+
+	due, err := date_utils.ParseDue(input, time.Now(), userLocation(userID))
+	if err != nil {
+		if errors.Is(err, date_utils.ErrUnrecognized) {
+			return "", fmt.Errorf("I couldn't understand that due date, try something like %s", dueDateExamples)
+		}
+		return "", err
+	}
+	return due.Format(time.RFC3339), nil
+}
+
+// validateDueDate is the wizard step validator for due dates
+func validateDueDate(ctx *WizardContext, input string) (string, error) {
+	return parseDueDate(ctx.UserID, input)
+}
+
+// isOverdue reports whether an unfinished task is past its due date
+func isOverdue(task *todo_utils.Task, now time.Time) bool {
+	due, ok := task.Due()
+	return ok && task.Status != "done" && due.Before(now)
+}
+
+// formatDueDate shows a due date in the user's timezone, e.g. "Tue 20 Oct 2026 09:00"
+func formatDueDate(userID string, due time.Time) string {
+	return due.In(userLocation(userID)).Format("Mon 02 Jan 2006 15:04")
+}
+
+// dueDateLabel is the due date part of a task line in the list, empty if there is none
+func dueDateLabel(userID string, task *todo_utils.Task, now time.Time) string {
+	due, ok := task.Due()
+	if !ok {
+		return ""
+	}
+	label := "📅 " + formatDueDate(userID, due)
+	if isOverdue(task, now) {
+		label += " ⚠️ **overdue**"
+	}
+	return label
+}
+
+// sortByDueDate orders tasks by due date, soonest first.
+// Tasks without a due date go last and keep their order.
+func sortByDueDate(tasks []todo_utils.Task) {
+	sort.SliceStable(tasks, func(a, b int) bool {
+		dueA, okA := tasks[a].Due()
+		dueB, okB := tasks[b].Due()
+		if okA != okB {
+			return okA
+		}
+		return okA && dueA.Before(dueB)
+	})
+}
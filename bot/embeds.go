@@ -0,0 +1,80 @@
+package bot
+
+import (
+	todo_utils "Discord_bot_v1/todo-utils"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Embed colors for task confirmations
+const (
+	embedColorCreated = 0x2ecc71
+	embedColorUpdated = 0x3498db
+	embedColorDeleted = 0xe74c3c
+)
+
+// taskEmbed builds the confirmation embed shown after a task is created, updated or deleted
+func taskEmbed(heading string, task *todo_utils.Task, color int) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       heading,
+		Description: fmt.Sprintf("**%s**", task.Title),
+		Color:       color,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Status", Value: fmt.Sprintf("%s %s", statusEmoji(task.Status), task.Status), Inline: true},
+		},
+	}
+
+	if task.Description != "" {
+		embed.Description += "\n" + task.Description
+	}
+	if task.Priority != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Priority", Value: strings.TrimSpace(priorityEmoji(task.Priority) + " " + task.Priority), Inline: true})
+	}
+	if len(task.Tags) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "🏷️ Tags", Value: tagBadges(task.Tags), Inline: true})
+	}
+	if due, ok := task.Due(); ok {
+		value := fmt.Sprintf("<t:%d:f> (<t:%d:R>)", due.Unix(), due.Unix())
+		if isOverdue(task, time.Now()) {
+			value += " ⚠️ overdue"
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "📅 Due", Value: value, Inline: true})
+	}
+	if task.ID != "" {
+		value := "`" + task.ID + "`"
+		if handle, ok := knownHandle(task.DiscordID, task.ID); ok && task.DiscordID != "" {
+			value = "`" + handle + "` · " + value
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "ID", Value: value, Inline: true})
+	}
+	if task.CreatedAt != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Created", Value: discordTimestamp(task.CreatedAt), Inline: true})
+	}
+	if task.UpdatedAt != "" && task.UpdatedAt != task.CreatedAt {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Updated", Value: discordTimestamp(task.UpdatedAt), Inline: true})
+	}
+
+	return embed
+}
+
+// discordTimestamp renders an RFC 3339 timestamp from the backend as a Discord
+// relative timestamp, falling back to the raw value if it can't be parsed
+func discordTimestamp(value string) string {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return value
+	}
+	return fmt.Sprintf("<t:%d:R>", t.Unix())
+}
+
+// respondEphemeralEmbed replies to an interaction with an embed only the user can see,
+// optionally with components such as an Undo button
+func respondEphemeralEmbed(s *discordgo.Session, i *discordgo.InteractionCreate, embed *discordgo.MessageEmbed, components ...discordgo.MessageComponent) {
+	respondEphemeralData(s, i, &discordgo.InteractionResponseData{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
+	})
+}
@@ -0,0 +1,27 @@
+package bot
+
+import (
+	todo_utils "Discord_bot_v1/todo-utils"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// todoErrorMessage turns an error from TodoApp into a message we can show the user.
+// The raw error is logged, since it may contain backend details.
+func todoErrorMessage(err error) string {
+	log.Printf("Todo API error: %v", err)
+
+	var apiErr *todo_utils.APIError
+	switch {
+	case errors.Is(err, todo_utils.ErrNotFound):
+		return fmt.Sprintf("❌ I couldn't find that task. It may have been deleted, run `%stodo-list` to refresh your list.", commandRouter.Prefix)
+	case errors.Is(err, todo_utils.ErrForbidden):
+		return "❌ You don't have access to that task."
+	case errors.Is(err, todo_utils.ErrValidation) && errors.As(err, &apiErr):
+		return fmt.Sprintf("❌ The todo service didn't accept that: %s", apiErr.Message)
+	case errors.Is(err, todo_utils.ErrUnavailable):
+		return "❌ The todo service is unavailable right now. Please try again in a bit."
+	}
+	return "❌ Something went wrong while talking to the todo service. Please try again."
+}
@@ -0,0 +1,214 @@
+package bot
+
+import (
+	todo_utils "Discord_bot_v1/todo-utils"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// handlesTTL is how long a user's task handles are kept without being used
+const handlesTTL = 365 * 24 * time.Hour
+
+// handlePrefix starts every task handle, e.g. T-42
+const handlePrefix = "T-"
+
+// handlePattern matches a typed handle: T-42, t-42 or T42
+var handlePattern = regexp.MustCompile(`^[Tt]-?([0-9]+)$`)
+
+// TaskHandles maps a user's tasks to short, stable numbers shown as T-<number>.
+// Numbers are handed out in order and never reused, so a handle keeps pointing
+// at the same task however the list is sorted, filtered or paged.
+type TaskHandles struct {
+	// Next is the number the next new task gets
+	Next int
+	// ByTask maps task IDs to their number
+	ByTask map[string]int
+}
+
+// userHandles stores the task handles of every user
+var userHandles StateStore[TaskHandles]
+
+// handlesMu serialises handing out numbers, so two handlers never give out the same one
+var handlesMu sync.Mutex
+
+// formatHandle renders a handle number, e.g. T-42
+func formatHandle(number int) string {
+	return handlePrefix + strconv.Itoa(number)
+}
+
+// parseHandle reads a typed handle like T-42 and returns its number
+func parseHandle(input string) (int, bool) {
+	match := handlePattern.FindStringSubmatch(input)
+	if match == nil {
+		return 0, false
+	}
+	number, err := strconv.Atoi(match[1])
+	return number, err == nil && number > 0
+}
+
+// assignHandles gives every task that doesn't have a handle yet the next free number,
+// oldest task first, and returns the handles of all of them by task ID
+func assignHandles(userID string, tasks []todo_utils.Task) map[string]string {
+	handlesMu.Lock()
+	defer handlesMu.Unlock()
+
+	handles, _ := userHandles.Get(userID)
+	if handles.ByTask == nil {
+		handles.ByTask = make(map[string]int)
+	}
+	if handles.Next == 0 {
+		handles.Next = 1
+	}
+
+	// Number new tasks in the order they were created
+	var unnumbered []todo_utils.Task
+	for _, task := range tasks {
+		if _, ok := handles.ByTask[task.ID]; !ok && task.ID != "" {
+			unnumbered = append(unnumbered, task)
+		}
+	}
+	sort.SliceStable(unnumbered, func(a, b int) bool {
+		return unnumbered[a].CreatedAt < unnumbered[b].CreatedAt
+	})
+	for _, task := range unnumbered {
+		handles.ByTask[task.ID] = handles.Next
+		handles.Next++
+	}
+
+	if len(unnumbered) > 0 {
+		userHandles.Set(userID, handles, handlesTTL)
+	} else {
+		userHandles.Touch(userID)
+	}
+
+	result := make(map[string]string, len(tasks))
+	for _, task := range tasks {
+		if number, ok := handles.ByTask[task.ID]; ok {
+			result[task.ID] = formatHandle(number)
+		}
+	}
+	return result
+}
+
+// taskHandle returns the handle of a task, giving it one if it has none yet
+func taskHandle(userID string, task *todo_utils.Task) string {
+	return assignHandles(userID, []todo_utils.Task{*task})[task.ID]
+}
+
+// knownHandle returns the handle a task already has, without giving it one
+func knownHandle(userID string, taskID string) (string, bool) {
+	handlesMu.Lock()
+	defer handlesMu.Unlock()
+
+	handles, _ := userHandles.Get(userID)
+	number, ok := handles.ByTask[taskID]
+	if !ok {
+		return "", false
+	}
+	return formatHandle(number), true
+}
+
+// handleNumber returns the number of a task's handle, 0 if it has none
+func handleNumber(userID string, taskID string) int {
+	handlesMu.Lock()
+	defer handlesMu.Unlock()
+
+	handles, _ := userHandles.Get(userID)
+	return handles.ByTask[taskID]
+}
+
+// rebindHandle gives a task a handle number it had before, e.g. when a deleted task is restored
+// under a new ID. Any handle the task got in the meantime is replaced.
+func rebindHandle(userID string, taskID string, number int) {
+	handlesMu.Lock()
+	defer handlesMu.Unlock()
+
+	handles, _ := userHandles.Get(userID)
+	if handles.ByTask == nil {
+		handles.ByTask = make(map[string]int)
+	}
+	handles.ByTask[taskID] = number
+	handles.Next = max(handles.Next, number+1)
+	userHandles.Set(userID, handles, handlesTTL)
+}
+
+// lookupHandle resolves a handle number to the task ID it was given to
+func lookupHandle(userID string, number int) (string, bool) {
+	handlesMu.Lock()
+	defer handlesMu.Unlock()
+
+	handles, _ := userHandles.Get(userID)
+	for taskID, n := range handles.ByTask {
+		if n == number {
+			return taskID, true
+		}
+	}
+	return "", false
+}
+
+// forgetHandle drops the handle of a deleted task. Its number is not given out again.
+func forgetHandle(userID string, taskID string) {
+	handlesMu.Lock()
+	defer handlesMu.Unlock()
+
+	handles, exists := userHandles.Get(userID)
+	if _, ok := handles.ByTask[taskID]; !exists || !ok {
+		return
+	}
+	delete(handles.ByTask, taskID)
+	userHandles.Set(userID, handles, handlesTTL)
+}
+
+// syncHandles drops the handles of tasks that are not in the user's complete task list,
+// e.g. ones deleted outside the bot
+func syncHandles(userID string, tasks []todo_utils.Task) {
+	handlesMu.Lock()
+	defer handlesMu.Unlock()
+
+	handles, exists := userHandles.Get(userID)
+	if !exists {
+		return
+	}
+
+	seen := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		seen[task.ID] = true
+	}
+	changed := false
+	for taskID := range handles.ByTask {
+		if !seen[taskID] {
+			delete(handles.ByTask, taskID)
+			changed = true
+		}
+	}
+	if changed {
+		userHandles.Set(userID, handles, handlesTTL)
+	}
+}
+
+// handleObserver hands out handles to tasks as they are created and drops them when deleted
+type handleObserver struct{}
+
+// TaskSaved implements todo_utils.Observer
+func (handleObserver) TaskSaved(discordID string, task *todo_utils.Task) {
+	if task.ID != "" {
+		taskHandle(discordID, task)
+	}
+}
+
+// TaskDeleted implements todo_utils.Observer
+func (handleObserver) TaskDeleted(discordID string, taskID string) {
+	forgetHandle(discordID, taskID)
+}
+
+// handleLabel is the handle of a task as shown in lists, e.g. "`T-42`"
+func handleLabel(userID string, task *todo_utils.Task) string {
+	if handle := taskHandle(userID, task); handle != "" {
+		return fmt.Sprintf("`%s`", handle)
+	}
+	return ""
+}
@@ -0,0 +1,166 @@
+package bot
+
+import (
+	"Discord_bot_v1/config"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// reminders sends due date reminders. Nil when reminders are disabled.
+var reminders *ReminderScheduler
+
+// Snooze choices offered on every reminder
+const (
+	reminderActionSnoozeHour     = "snooze1h"
+	reminderActionSnoozeTomorrow = "snoozetomorrow"
+	reminderActionDone           = "done"
+)
+
+// snoozeTomorrowHour is the hour of the day "tomorrow" snoozes until, in the user's timezone
+const snoozeTomorrowHour = 9
+
+// startReminders sets up the reminder scheduler and runs it until stop is closed.
+// Start hooks it into the todo client.
+func startReminders(s *discordgo.Session, cfg config.RemindersConfig, store ReminderStore, stop <-chan struct{}) error {
+	scheduler, err := NewReminderScheduler(store, cfg.Offsets, cfg.Overdue)
+	if err != nil {
+		return fmt.Errorf("failed to load reminders: %w", err)
+	}
+	scheduler.Notify = func(notice ReminderNotice) error {
+		return sendReminder(s, notice, scheduler.Now())
+	}
+	// Sync drops reminders of tasks missing from the list, so it needs all of them
+	scheduler.Fetch = fetchCompleteTasks
+
+	reminders = scheduler
+
+	go scheduler.Run(cfg.CheckInterval, cfg.PollInterval, stop)
+	return nil
+}
+
+// reminderMessage is the text of a reminder DM
+func reminderMessage(notice ReminderNotice, now time.Time) string {
+	r := notice.Reminder
+	due := r.Due.Unix()
+
+	var message string
+	if r.Due.After(now) {
+		message = fmt.Sprintf("⏰ Reminder: **%s** is due <t:%d:R> (<t:%d:f>).", r.Title, due, due)
+	} else {
+		message = fmt.Sprintf("⚠️ **%s** is overdue, it was due <t:%d:R>.", r.Title, due)
+	}
+	if notice.Snoozed {
+		message = "💤 Snoozed reminder\n" + message
+	}
+	return message
+}
+
+// sendReminder DMs a reminder with its snooze buttons
+func sendReminder(s *discordgo.Session, notice ReminderNotice, now time.Time) error {
+	dmChannel, err := s.UserChannelCreate(notice.Reminder.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to create DM channel: %w", err)
+	}
+
+	taskID := notice.Reminder.TaskID
+	_, err = s.ChannelMessageSendComplex(dmChannel.ID, &discordgo.MessageSend{
+		Content: reminderMessage(notice, now),
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "💤 1 hour",
+					Style:    discordgo.SecondaryButton,
+					CustomID: "remind_" + reminderActionSnoozeHour + "_" + taskID,
+				},
+				discordgo.Button{
+					Label:    "💤 Tomorrow",
+					Style:    discordgo.SecondaryButton,
+					CustomID: "remind_" + reminderActionSnoozeTomorrow + "_" + taskID,
+				},
+				discordgo.Button{
+					Label:    "✅ Done",
+					Style:    discordgo.SuccessButton,
+					CustomID: "remind_" + reminderActionDone + "_" + taskID,
+				},
+			}},
+		},
+	})
+	return err
+}
+
+// handleReminderComponent handles the buttons on a reminder.
+// Custom IDs look like remind_<action>_<taskID>.
+func handleReminderComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	parts := strings.SplitN(i.MessageComponentData().CustomID, "_", 3)
+	if len(parts) != 3 || parts[0] != "remind" {
+		return
+	}
+	action, taskID := parts[1], parts[2]
+
+	userID := interactionUserID(i)
+	if userID == "" {
+		return
+	}
+
+	var notice string
+	components := []discordgo.MessageComponent{}
+	switch action {
+	case reminderActionSnoozeHour, reminderActionSnoozeTomorrow:
+		if reminders == nil {
+			// A reminder sent before reminders were turned off
+			respondEphemeral(s, i, "🔕 Reminders are turned off on this bot, so there's nothing to snooze.")
+			return
+		}
+		until := reminders.Now().Add(time.Hour)
+		if action == reminderActionSnoozeTomorrow {
+			tomorrow := reminders.Now().In(userLocation(userID)).AddDate(0, 0, 1)
+			until = time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), snoozeTomorrowHour, 0, 0, 0, tomorrow.Location())
+		}
+		if !reminders.Snooze(userID, taskID, until) {
+			respondEphemeral(s, i, "❌ This task has no reminders anymore, it may have been completed or deleted.")
+			return
+		}
+		notice = fmt.Sprintf("💤 Snoozed until <t:%d:f>.", until.Unix())
+
+	case reminderActionDone:
+		defer deferInteraction(s, i)()
+		ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+		defer cancel()
+
+		task, err := TodoApp.GetTask(ctx, taskID, userID)
+		if err != nil {
+			respondEphemeral(s, i, todoErrorMessage(err))
+			return
+		}
+		input := task.Input()
+		input.Status = "done"
+		_, undoID, err := updateTaskWithUndo(ctx, userID, task, input)
+		if err != nil {
+			respondEphemeral(s, i, todoErrorMessage(err))
+			return
+		}
+		notice = fmt.Sprintf("✅ **%s** is now done.", task.Title)
+		components = undoComponents(undoID)
+
+	default:
+		return
+	}
+
+	// Keep the reminder text, swap the buttons for the outcome (and the Undo button if there is one)
+	content := notice
+	if i.Message != nil {
+		content = i.Message.Content + "\n" + notice
+	}
+	err := updateMessage(s, i, &discordgo.InteractionResponseData{
+		Content:    content,
+		Components: components,
+	})
+	if err != nil {
+		log.Printf("Failed to update reminder: %v", err)
+	}
+}
@@ -0,0 +1,397 @@
+package bot
+
+import (
+	todo_utils "Discord_bot_v1/todo-utils"
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// reminderStageOverdue is the stage fired once a task's due date has passed
+const reminderStageOverdue = "overdue"
+
+// reminderMaxAttempts is how often sending a reminder is tried before it is dropped
+const reminderMaxAttempts = 3
+
+// Reminder tracks the reminders of one task with a due date
+type Reminder struct {
+	TaskID string    `json:"task_id"`
+	UserID string    `json:"user_id"`
+	Title  string    `json:"title"`
+	Due    time.Time `json:"due"`
+	// Sent holds the stages already handled: offsets like "1h0m0s" and reminderStageOverdue
+	Sent []string `json:"sent"`
+	// SnoozedUntil is when a snoozed reminder is sent again. Zero when not snoozed.
+	SnoozedUntil time.Time `json:"snoozed_until"`
+	// Attempts counts failed sends of the current reminder
+	Attempts int `json:"attempts"`
+}
+
+// sent reports whether the stage has already been handled
+func (r *Reminder) sent(stage string) bool {
+	for _, s := range r.Sent {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// ReminderNotice is a reminder that is due to be sent
+type ReminderNotice struct {
+	Reminder Reminder
+	// Stage is the offset or reminderStageOverdue that fired
+	Stage string
+	// Snoozed is true when this is a snoozed reminder coming back
+	Snoozed bool
+}
+
+// ReminderStore persists reminders, keyed by task ID
+type ReminderStore interface {
+	// All returns every stored reminder
+	All() ([]Reminder, error)
+	// Put stores or replaces a reminder
+	Put(r Reminder) error
+	// Delete removes a reminder
+	Delete(taskID string) error
+}
+
+// MemoryReminderStore is a ReminderStore that forgets everything on restart
+type MemoryReminderStore struct {
+	mu        sync.Mutex
+	reminders map[string]Reminder
+}
+
+// NewMemoryReminderStore creates an empty store
+func NewMemoryReminderStore() *MemoryReminderStore {
+	return &MemoryReminderStore{reminders: make(map[string]Reminder)}
+}
+
+func (m *MemoryReminderStore) All() ([]Reminder, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	all := make([]Reminder, 0, len(m.reminders))
+	for _, r := range m.reminders {
+		all = append(all, r)
+	}
+	return all, nil
+}
+
+func (m *MemoryReminderStore) Put(r Reminder) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reminders[r.TaskID] = r
+	return nil
+}
+
+func (m *MemoryReminderStore) Delete(taskID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.reminders, taskID)
+	return nil
+}
+
+// ReminderScheduler sends reminders at Offsets before a task's due date, and once
+// more when it is overdue if Overdue is set.
+//
+// It learns about tasks through the todo_utils.Observer methods and Sync, and keeps
+// its reminders in a ReminderStore. A stage is recorded as sent before it is handed
+// to Notify, so a restart never sends it twice; if Notify fails it is retried on the
+// next checks, up to reminderMaxAttempts times.
+//
+// Now and Notify are injected so the scheduler can be driven without Discord or a real clock.
+type ReminderScheduler struct {
+	Offsets []time.Duration
+	Overdue bool
+	// Now returns the current time
+	Now func() time.Time
+	// Notify sends a reminder to its user
+	Notify func(notice ReminderNotice) error
+	// Fetch returns every task of a user, used by Poll
+	Fetch func(ctx context.Context, userID string) ([]todo_utils.Task, error)
+
+	mu        sync.Mutex
+	store     ReminderStore
+	reminders map[string]*Reminder
+}
+
+// NewReminderScheduler loads the stored reminders. Offsets are sorted, longest first.
+func NewReminderScheduler(store ReminderStore, offsets []time.Duration, overdue bool) (*ReminderScheduler, error) {
+	stored, err := store.All()
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := append([]time.Duration(nil), offsets...)
+	sort.Slice(sorted, func(a, b int) bool { return sorted[a] > sorted[b] })
+
+	scheduler := &ReminderScheduler{
+		Offsets:   sorted,
+		Overdue:   overdue,
+		Now:       time.Now,
+		store:     store,
+		reminders: make(map[string]*Reminder, len(stored)),
+	}
+	for i := range stored {
+		scheduler.reminders[stored[i].TaskID] = &stored[i]
+	}
+	return scheduler, nil
+}
+
+// reminderStage is a point in time a reminder fires at
+type reminderStage struct {
+	name   string
+	fireAt time.Time
+}
+
+// stages returns every stage of a reminder, in the order they fire
+func (rs *ReminderScheduler) stages(r *Reminder) []reminderStage {
+	stages := make([]reminderStage, 0, len(rs.Offsets)+1)
+	for _, offset := range rs.Offsets {
+		stages = append(stages, reminderStage{name: offset.String(), fireAt: r.Due.Add(-offset)})
+	}
+	if rs.Overdue {
+		stages = append(stages, reminderStage{name: reminderStageOverdue, fireAt: r.Due})
+	}
+	return stages
+}
+
+// markPassed records every stage that fired at or before now as sent.
+// It returns the last of the newly marked stages, or "" if there were none.
+func (rs *ReminderScheduler) markPassed(r *Reminder, now time.Time) string {
+	latest := ""
+	for _, stage := range rs.stages(r) {
+		if !stage.fireAt.After(now) && !r.sent(stage.name) {
+			r.Sent = append(r.Sent, stage.name)
+			latest = stage.name
+		}
+	}
+	return latest
+}
+
+// save persists a reminder. Failures are logged, memory stays authoritative.
+func (rs *ReminderScheduler) save(r *Reminder) {
+	if err := rs.store.Put(*r); err != nil {
+		log.Printf("Failed to persist reminder for task %s: %v", r.TaskID, err)
+	}
+}
+
+// forget drops a reminder
+func (rs *ReminderScheduler) forget(taskID string) {
+	if _, exists := rs.reminders[taskID]; !exists {
+		return
+	}
+	delete(rs.reminders, taskID)
+	if err := rs.store.Delete(taskID); err != nil {
+		log.Printf("Failed to remove reminder for task %s: %v", taskID, err)
+	}
+}
+
+// track starts, updates or stops the reminders of a task. Must hold rs.mu.
+func (rs *ReminderScheduler) track(userID string, task *todo_utils.Task) {
+	due, ok := task.Due()
+	if !ok || task.Status == "done" || task.ID == "" {
+		rs.forget(task.ID)
+		return
+	}
+
+	r, exists := rs.reminders[task.ID]
+	if exists && r.Due.Equal(due) {
+		// Same due date, nothing to reschedule
+		if r.Title != task.Title {
+			r.Title = task.Title
+			rs.save(r)
+		}
+		return
+	}
+
+	// New task or a new due date. Stages that are already past are skipped,
+	// a task created an hour before it is due shouldn't get a "1 day left" reminder.
+	r = &Reminder{TaskID: task.ID, UserID: userID, Title: task.Title, Due: due}
+	rs.markPassed(r, rs.Now())
+	rs.reminders[task.ID] = r
+	rs.save(r)
+}
+
+// TaskSaved implements todo_utils.Observer
+func (rs *ReminderScheduler) TaskSaved(discordID string, task *todo_utils.Task) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.track(discordID, task)
+}
+
+// TaskDeleted implements todo_utils.Observer
+func (rs *ReminderScheduler) TaskDeleted(discordID string, taskID string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.forget(taskID)
+}
+
+// Sync brings the user's reminders in line with their complete task list.
+// Reminders of tasks that are no longer in the list are dropped.
+func (rs *ReminderScheduler) Sync(userID string, tasks []todo_utils.Task) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	seen := make(map[string]bool, len(tasks))
+	for i := range tasks {
+		seen[tasks[i].ID] = true
+		rs.track(userID, &tasks[i])
+	}
+	for taskID, r := range rs.reminders {
+		if r.UserID == userID && !seen[taskID] {
+			rs.forget(taskID)
+		}
+	}
+}
+
+// Snooze holds back a task's reminders until the given time, then reminds once more.
+// It reports false if the user has no reminders for the task.
+func (rs *ReminderScheduler) Snooze(userID string, taskID string, until time.Time) bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	r, exists := rs.reminders[taskID]
+	if !exists || r.UserID != userID {
+		return false
+	}
+	r.SnoozedUntil = until
+	r.Attempts = 0
+	rs.save(r)
+	return true
+}
+
+// Check sends every reminder that is due. Only the latest passed stage of a task is
+// sent, so a bot that was offline for a while doesn't send a burst of stale reminders.
+func (rs *ReminderScheduler) Check() {
+	now := rs.Now()
+	var notices []ReminderNotice
+
+	rs.mu.Lock()
+	for _, r := range rs.reminders {
+		if !r.SnoozedUntil.IsZero() {
+			// Stages passing during a snooze are covered by the snoozed reminder
+			rs.markPassed(r, now)
+			if now.Before(r.SnoozedUntil) {
+				rs.save(r)
+				continue
+			}
+			notices = append(notices, ReminderNotice{Reminder: *r, Stage: rs.latestStage(r, now), Snoozed: true})
+			r.SnoozedUntil = time.Time{}
+			rs.save(r)
+			continue
+		}
+
+		if stage := rs.markPassed(r, now); stage != "" {
+			notices = append(notices, ReminderNotice{Reminder: *r, Stage: stage})
+			rs.save(r)
+		}
+	}
+	rs.mu.Unlock()
+
+	for _, notice := range notices {
+		if err := rs.Notify(notice); err != nil {
+			log.Printf("Failed to send reminder for task %s to user %s: %v", notice.Reminder.TaskID, notice.Reminder.UserID, err)
+			rs.retry(notice)
+			continue
+		}
+		rs.sent(notice.Reminder.TaskID)
+	}
+}
+
+// latestStage returns the last stage that fired at or before now, or "" if none has
+func (rs *ReminderScheduler) latestStage(r *Reminder, now time.Time) string {
+	latest := ""
+	for _, stage := range rs.stages(r) {
+		if !stage.fireAt.After(now) {
+			latest = stage.name
+		}
+	}
+	return latest
+}
+
+// retry puts a failed reminder back so the next Check sends it again
+func (rs *ReminderScheduler) retry(notice ReminderNotice) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	r, exists := rs.reminders[notice.Reminder.TaskID]
+	if !exists {
+		return
+	}
+	r.Attempts++
+	if r.Attempts >= reminderMaxAttempts {
+		log.Printf("Giving up on reminder for task %s after %d attempts", r.TaskID, r.Attempts)
+		r.Attempts = 0
+		rs.save(r)
+		return
+	}
+
+	if notice.Snoozed {
+		r.SnoozedUntil = rs.Now()
+	} else {
+		for i, stage := range r.Sent {
+			if stage == notice.Stage {
+				r.Sent = append(r.Sent[:i], r.Sent[i+1:]...)
+				break
+			}
+		}
+	}
+	rs.save(r)
+}
+
+// sent clears the failure count after a reminder went out
+func (rs *ReminderScheduler) sent(taskID string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if r, exists := rs.reminders[taskID]; exists && r.Attempts > 0 {
+		r.Attempts = 0
+		rs.save(r)
+	}
+}
+
+// Poll re-reads the tasks of every user with reminders, so changes made outside
+// the bot are picked up. Every user gets their own commandTimeout under ctx, so a
+// slow fetch for one user doesn't use up the time of the users after them.
+func (rs *ReminderScheduler) Poll(ctx context.Context) {
+	rs.mu.Lock()
+	users := make(map[string]bool)
+	for _, r := range rs.reminders {
+		users[r.UserID] = true
+	}
+	rs.mu.Unlock()
+
+	for userID := range users {
+		userCtx, cancel := context.WithTimeout(ctx, commandTimeout)
+		tasks, err := rs.Fetch(userCtx, userID)
+		cancel()
+		if err != nil {
+			log.Printf("Failed to refresh reminders for user %s: %v", userID, err)
+			continue
+		}
+		rs.Sync(userID, tasks)
+	}
+}
+
+// Run checks reminders every checkInterval and polls the backend every pollInterval,
+// until stop is closed
+func (rs *ReminderScheduler) Run(checkInterval time.Duration, pollInterval time.Duration, stop <-chan struct{}) {
+	checkTicker := time.NewTicker(checkInterval)
+	defer checkTicker.Stop()
+	pollTicker := time.NewTicker(pollInterval)
+	defer pollTicker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-checkTicker.C:
+			rs.Check()
+		case <-pollTicker.C:
+			rs.Poll(context.Background())
+		}
+	}
+}
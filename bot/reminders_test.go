@@ -0,0 +1,192 @@
+package bot
+
+import (
+	todo_utils "Discord_bot_v1/todo-utils"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock the test moves by hand
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time          { return c.now }
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// newTestScheduler returns a scheduler on a fake clock that records what it sends
+func newTestScheduler(t *testing.T, offsets []time.Duration, overdue bool) (*ReminderScheduler, *fakeClock, *[]ReminderNotice) {
+	t.Helper()
+	rs, err := NewReminderScheduler(NewMemoryReminderStore(), offsets, overdue)
+	if err != nil {
+		t.Fatalf("NewReminderScheduler: %v", err)
+	}
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)}
+	rs.Now = clock.Now
+
+	var sent []ReminderNotice
+	rs.Notify = func(notice ReminderNotice) error {
+		sent = append(sent, notice)
+		return nil
+	}
+	return rs, clock, &sent
+}
+
+// expectNotices checks the stages sent since the last call and forgets them
+func expectNotices(t *testing.T, sent *[]ReminderNotice, stages ...string) {
+	t.Helper()
+	if len(*sent) != len(stages) {
+		t.Fatalf("got %d reminders %v, want %v", len(*sent), *sent, stages)
+	}
+	for i, stage := range stages {
+		if (*sent)[i].Stage != stage {
+			t.Errorf("reminder %d is stage %q, want %q", i, (*sent)[i].Stage, stage)
+		}
+	}
+	*sent = nil
+}
+
+func dueTask(id string, due time.Time) *todo_utils.Task {
+	return &todo_utils.Task{ID: id, Title: "Send the invoice", Status: "backlog", DueDate: due.Format(time.RFC3339)}
+}
+
+func TestReminderOffsets(t *testing.T) {
+	rs, clock, sent := newTestScheduler(t, []time.Duration{time.Hour, 24 * time.Hour}, true)
+	due := clock.Now().Add(48 * time.Hour)
+	rs.TaskSaved("user", dueTask("1", due))
+
+	rs.Check()
+	expectNotices(t, sent)
+
+	clock.Advance(25 * time.Hour)
+	rs.Check()
+	expectNotices(t, sent, (24 * time.Hour).String())
+
+	// A stage is only sent once
+	rs.Check()
+	expectNotices(t, sent)
+
+	clock.Advance(22*time.Hour + 30*time.Minute)
+	rs.Check()
+	expectNotices(t, sent, time.Hour.String())
+}
+
+func TestReminderSkipsPassedStages(t *testing.T) {
+	rs, clock, sent := newTestScheduler(t, []time.Duration{24 * time.Hour}, true)
+
+	// Created with less than a day left, so the 24h stage is already past
+	rs.TaskSaved("user", dueTask("1", clock.Now().Add(2*time.Hour)))
+	rs.Check()
+	expectNotices(t, sent)
+
+	// Done tasks are not reminded of
+	done := dueTask("2", clock.Now().Add(time.Hour))
+	done.Status = "done"
+	rs.TaskSaved("user", done)
+	clock.Advance(3 * time.Hour)
+	rs.Check()
+	expectNotices(t, sent, reminderStageOverdue)
+}
+
+func TestReminderOverdue(t *testing.T) {
+	rs, clock, sent := newTestScheduler(t, []time.Duration{time.Hour, 24 * time.Hour}, true)
+	rs.TaskSaved("user", dueTask("1", clock.Now().Add(48*time.Hour)))
+
+	// Offline past every stage: only the latest one goes out
+	clock.Advance(50 * time.Hour)
+	rs.Check()
+	expectNotices(t, sent, reminderStageOverdue)
+
+	rs.Check()
+	expectNotices(t, sent)
+}
+
+func TestReminderOverdueOff(t *testing.T) {
+	rs, clock, sent := newTestScheduler(t, []time.Duration{time.Hour}, false)
+	rs.TaskSaved("user", dueTask("1", clock.Now().Add(2*time.Hour)))
+
+	clock.Advance(90 * time.Minute)
+	rs.Check()
+	expectNotices(t, sent, time.Hour.String())
+
+	clock.Advance(time.Hour)
+	rs.Check()
+	expectNotices(t, sent)
+}
+
+func TestReminderSnooze(t *testing.T) {
+	rs, clock, sent := newTestScheduler(t, []time.Duration{time.Hour}, true)
+	rs.TaskSaved("user", dueTask("1", clock.Now().Add(2*time.Hour)))
+
+	clock.Advance(90 * time.Minute)
+	rs.Check()
+	expectNotices(t, sent, time.Hour.String())
+
+	if rs.Snooze("someone else", "1", clock.Now().Add(time.Hour)) {
+		t.Fatal("snoozed another user's reminder")
+	}
+	if !rs.Snooze("user", "1", clock.Now().Add(time.Hour)) {
+		t.Fatal("Snooze reported no reminder")
+	}
+
+	// The due date passes during the snooze, it is covered by the snoozed reminder
+	clock.Advance(45 * time.Minute)
+	rs.Check()
+	expectNotices(t, sent)
+
+	clock.Advance(15 * time.Minute)
+	rs.Check()
+	if len(*sent) != 1 || !(*sent)[0].Snoozed {
+		t.Fatalf("got %v, want one snoozed reminder", *sent)
+	}
+	expectNotices(t, sent, reminderStageOverdue)
+
+	rs.Check()
+	expectNotices(t, sent)
+}
+
+func TestReminderRetry(t *testing.T) {
+	rs, clock, sent := newTestScheduler(t, []time.Duration{time.Hour}, false)
+	rs.TaskSaved("user", dueTask("1", clock.Now().Add(2*time.Hour)))
+
+	notify := rs.Notify
+	failures := 0
+	rs.Notify = func(notice ReminderNotice) error {
+		if failures < reminderMaxAttempts {
+			failures++
+			return errors.New("discord is down")
+		}
+		return notify(notice)
+	}
+
+	clock.Advance(90 * time.Minute)
+	for i := 0; i < reminderMaxAttempts; i++ {
+		rs.Check()
+	}
+	expectNotices(t, sent)
+
+	// Given up after reminderMaxAttempts, the stage stays sent
+	rs.Check()
+	expectNotices(t, sent)
+}
+
+func TestReminderSync(t *testing.T) {
+	rs, clock, sent := newTestScheduler(t, []time.Duration{time.Hour}, true)
+	rs.TaskSaved("user", dueTask("1", clock.Now().Add(2*time.Hour)))
+	rs.TaskSaved("other", dueTask("2", clock.Now().Add(2*time.Hour)))
+
+	// Task 1 was deleted outside the bot, task 3 was added
+	rs.Sync("user", []todo_utils.Task{*dueTask("3", clock.Now().Add(3*time.Hour))})
+
+	clock.Advance(150 * time.Minute)
+	rs.Check()
+	if len(*sent) != 2 {
+		t.Fatalf("got %v, want reminders for tasks 2 and 3", *sent)
+	}
+	for _, notice := range *sent {
+		if notice.Reminder.TaskID == "1" {
+			t.Errorf("got a reminder for a task that is gone")
+		}
+	}
+}
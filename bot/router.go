@@ -0,0 +1,201 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Command describes a single prefix command, e.g. `!todo-list`.
+// Commands register themselves on commandRouter from their own files.
+type Command struct {
+	// Name is the command token without the prefix, e.g. "todo-list"
+	Name string
+	// Aliases are alternative tokens that trigger the same command
+	Aliases []string
+	// Usage describes the arguments, e.g. "<number>". Empty if the command takes none.
+	Usage string
+	// Description is the one-line summary shown in !help
+	Description string
+	// Category groups commands in !help, e.g. "General" or "Task Management"
+	Category string
+	// Parse turns the raw argument string into the value passed in CommandContext.Args.
+	// If it returns an error, the user gets the error and the usage string and Handler is not called.
+	// Optional: if nil, Args is left nil and the handler can read RawArgs directly.
+	Parse func(raw string) (interface{}, error)
+	// Handler runs the command
+	Handler func(ctx *CommandContext)
+}
+
+// CommandContext is passed to a command handler for a single message
+type CommandContext struct {
+	// Ctx bounds the backend calls made by the handler
+	Ctx     context.Context
+	Session *discordgo.Session
+	Message *discordgo.MessageCreate
+	// RawArgs is everything after the command token, trimmed
+	RawArgs string
+	// Args is the result of Command.Parse, if the command has one
+	Args interface{}
+
+	dmChannelID string
+}
+
+// Reply sends a message to the channel the command was sent in
+func (c *CommandContext) Reply(content string) {
+	c.Session.ChannelMessageSend(c.Message.ChannelID, content)
+}
+
+// DM returns the ID of the DM channel with the author, creating it on first use.
+// If we can't create a DM channel, it falls back to the original channel.
+func (c *CommandContext) DM() string {
+	if c.dmChannelID == "" {
+		c.dmChannelID = dmChannelID(c.Session, c.Message.Author.ID, c.Message.ChannelID)
+	}
+	return c.dmChannelID
+}
+
+// SendDM sends a message to the author's DMs
+func (c *CommandContext) SendDM(content string) {
+	c.Session.ChannelMessageSend(c.DM(), content)
+}
+
+// NotifyDM tells the author to check their DMs, but only when the command
+// was sent in a server channel
+func (c *CommandContext) NotifyDM(what string) {
+	channel, err := c.Session.State.Channel(c.Message.ChannelID)
+	if err == nil && channel.Type != discordgo.ChannelTypeDM {
+		c.Reply(fmt.Sprintf("<@%s> Please check your DMs %s!", c.Message.Author.ID, what))
+	}
+}
+
+// dmChannelID returns the DM channel with the user, or fallback if it can't be created
+func dmChannelID(s *discordgo.Session, userID string, fallback string) string {
+	dmChannel, err := s.UserChannelCreate(userID)
+	if err != nil {
+		log.Printf("Failed to create DM channel for user %s: %v", userID, err)
+		return fallback
+	}
+	return dmChannel.ID
+}
+
+// Router dispatches prefix commands to their registered handlers
+type Router struct {
+	Prefix string
+
+	// commands keeps registration order so !help is stable
+	commands []*Command
+	// lookup maps every name and alias to its command
+	lookup map[string]*Command
+}
+
+// NewRouter creates an empty router for the given command prefix
+func NewRouter(prefix string) *Router {
+	return &Router{
+		Prefix: prefix,
+		lookup: make(map[string]*Command),
+	}
+}
+
+// commandRouter is the router every prefix command registers itself on
+var commandRouter = NewRouter("!")
+
+// Register adds a command to the router. Registering the same name or alias
+// twice is a programming error and panics.
+func (r *Router) Register(cmd *Command) {
+	if cmd.Name == "" || cmd.Handler == nil {
+		panic("bot: command must have a name and a handler")
+	}
+	for _, token := range append([]string{cmd.Name}, cmd.Aliases...) {
+		if _, exists := r.lookup[token]; exists {
+			panic(fmt.Sprintf("bot: command %q registered twice", token))
+		}
+		r.lookup[token] = cmd
+	}
+	r.commands = append(r.commands, cmd)
+}
+
+// Dispatch runs the command matching the message, if any, and reports whether one ran.
+// Only the first whitespace separated token is matched, and it must match exactly,
+// so `!summarize-link` never triggers `!summarize`.
+func (r *Router) Dispatch(s *discordgo.Session, m *discordgo.MessageCreate) bool {
+	content := strings.TrimSpace(m.Content)
+	if !strings.HasPrefix(content, r.Prefix) {
+		return false
+	}
+
+	token, rawArgs := content, ""
+	if idx := strings.IndexFunc(content, unicode.IsSpace); idx >= 0 {
+		token, rawArgs = content[:idx], content[idx:]
+	}
+
+	cmd, ok := r.lookup[strings.TrimPrefix(token, r.Prefix)]
+	if !ok {
+		return false
+	}
+
+	callCtx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	ctx := &CommandContext{
+		Ctx:     callCtx,
+		Session: s,
+		Message: m,
+		RawArgs: strings.TrimSpace(rawArgs),
+	}
+
+	if cmd.Parse != nil {
+		args, err := cmd.Parse(ctx.RawArgs)
+		if err != nil {
+			ctx.Reply(fmt.Sprintf("❌ %v. Usage: `%s`", err, r.usage(cmd)))
+			return true
+		}
+		ctx.Args = args
+	}
+
+	cmd.Handler(ctx)
+	return true
+}
+
+// usage returns the full usage line of a command, e.g. `!todo-update <number>`
+func (r *Router) usage(cmd *Command) string {
+	if cmd.Usage == "" {
+		return r.Prefix + cmd.Name
+	}
+	return r.Prefix + cmd.Name + " " + cmd.Usage
+}
+
+// HelpText builds the help message from the registered commands, grouped by category
+func (r *Router) HelpText(username string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Hey %s! 👋 I'm WinayaBot, here to help you manage your tasks and more!\n\n", username)
+
+	// Keep categories in the order they were first registered
+	var categories []string
+	byCategory := make(map[string][]*Command)
+	for _, cmd := range r.commands {
+		if _, seen := byCategory[cmd.Category]; !seen {
+			categories = append(categories, cmd.Category)
+		}
+		byCategory[cmd.Category] = append(byCategory[cmd.Category], cmd)
+	}
+
+	for _, category := range categories {
+		fmt.Fprintf(&b, "**%s:**\n", category)
+		for _, cmd := range byCategory[category] {
+			fmt.Fprintf(&b, "• `%s` - %s", r.usage(cmd), cmd.Description)
+			if len(cmd.Aliases) > 0 {
+				fmt.Fprintf(&b, " (aliases: `%s%s`)", r.Prefix, strings.Join(cmd.Aliases, "`, `"+r.Prefix))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("Just type any command to get started!")
+	return b.String()
+}
@@ -0,0 +1,239 @@
+package bot
+
+import (
+	todo_utils "Discord_bot_v1/todo-utils"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// searchResultLimit is how many search results are shown at most
+const searchResultLimit = 10
+
+// Match scores for a single search term against a single word.
+// A term found in the title counts titleWeight times as much as one in the description.
+const (
+	scoreExact   = 1.0
+	scoreSynonym = 0.9
+	scorePrefix  = 0.8
+	scoreInfix   = 0.6
+	scoreTypo    = 0.7
+	// typoPenalty is taken off scoreTypo for every edit needed
+	typoPenalty = 0.15
+	titleWeight = 2.0
+	// phraseBonus is added when the whole query appears as is in the title
+	phraseBonus = 1.0
+)
+
+// indonesianSuffixes are stripped from words before matching, so "laporannya" finds "laporan"
+var indonesianSuffixes = []string{"nya", "kan", "lah", "kah"}
+
+// synonymGroups are words that mean the same in Indonesian and English.
+// Users often mix both, so a search for "laporan" should find "report".
+var synonymGroups = [][]string{
+	{"laporan", "lapor", "report"},
+	{"rapat", "meeting", "meet"},
+	{"tugas", "task", "todo"},
+	{"kirim", "send"},
+	{"faktur", "tagihan", "invoice", "bill"},
+	{"bayar", "pay", "payment", "pembayaran"},
+	{"perbaiki", "benerin", "fix", "repair"},
+	{"desain", "design"},
+	{"beli", "buy", "belanja", "shopping"},
+	{"jadwal", "schedule"},
+	{"presentasi", "presentation", "slides"},
+	{"uji", "tes", "test", "testing"},
+	{"surel", "email", "mail"},
+	{"dokumen", "document", "doc", "docs"},
+	{"kode", "code"},
+	{"tinjau", "review"},
+	{"panggil", "telepon", "call"},
+	{"belajar", "study", "learn"},
+}
+
+// synonyms maps every word in synonymGroups to the first word of its group
+var synonyms = func() map[string]string {
+	m := make(map[string]string)
+	for _, group := range synonymGroups {
+		for _, word := range group {
+			m[word] = group[0]
+		}
+	}
+	return m
+}()
+
+// searchWords splits text into lowercase words of letters and digits,
+// with common Indonesian suffixes removed
+func searchWords(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	words := make([]string, 0, len(fields))
+	for _, word := range fields {
+		words = append(words, stemWord(word))
+	}
+	return words
+}
+
+// stemWord strips one Indonesian suffix, as long as a real word is left over
+func stemWord(word string) string {
+	if _, known := synonyms[word]; known {
+		return word
+	}
+	for _, suffix := range indonesianSuffixes {
+		if stem := strings.TrimSuffix(word, suffix); stem != word && len([]rune(stem)) >= 3 {
+			return stem
+		}
+	}
+	return word
+}
+
+// maxTypos is how many edits a search term of the given length tolerates
+func maxTypos(length int) int {
+	switch {
+	case length < 4:
+		return 0
+	case length < 8:
+		return 1
+	}
+	return 2
+}
+
+// termScore is how well a search term matches the best of the words, 0 if none does
+func termScore(term string, words []string) float64 {
+	best := 0.0
+	termLength := len([]rune(term))
+	for _, word := range words {
+		score := 0.0
+		switch {
+		case word == term:
+			score = scoreExact
+		case synonyms[word] != "" && synonyms[word] == synonyms[term]:
+			score = scoreSynonym
+		case termLength >= 2 && strings.HasPrefix(word, term):
+			score = scorePrefix
+		case termLength >= 3 && strings.Contains(word, term):
+			score = scoreInfix
+		default:
+			if d := editDistance(term, word); d <= maxTypos(termLength) {
+				score = scoreTypo - typoPenalty*float64(d)
+			}
+		}
+		best = max(best, score)
+	}
+	return best
+}
+
+// searchScore rates a task against the search terms. Tasks matching fewer than
+// half of the terms score 0, so a single common word doesn't drag in everything.
+func searchScore(task *todo_utils.Task, phrase string, terms []string) float64 {
+	titleWords := searchWords(task.Title + " " + strings.Join(task.Tags, " "))
+	descriptionWords := searchWords(task.Description)
+
+	score, matched := 0.0, 0
+	for _, term := range terms {
+		termBest := max(titleWeight*termScore(term, titleWords), termScore(term, descriptionWords))
+		if termBest > 0 {
+			matched++
+		}
+		score += termBest
+	}
+	if matched*2 < len(terms) {
+		return 0
+	}
+
+	if strings.Contains(strings.ToLower(task.Title), phrase) {
+		score += phraseBonus
+	}
+	return score
+}
+
+// searchTasks returns the tasks matching the query, best match first.
+// Titles, tags and descriptions are searched; typos and Indonesian/English
+// synonyms are tolerated. Equally good matches keep the due date order.
+func searchTasks(tasks []todo_utils.Task, query string) []todo_utils.Task {
+	terms := searchWords(query)
+	if len(terms) == 0 {
+		return nil
+	}
+	phrase := strings.ToLower(strings.TrimSpace(query))
+
+	sorted := append([]todo_utils.Task(nil), tasks...)
+	sortByDueDate(sorted)
+
+	type result struct {
+		task  todo_utils.Task
+		score float64
+	}
+	var results []result
+	for i := range sorted {
+		if score := searchScore(&sorted[i], phrase, terms); score > 0 {
+			results = append(results, result{task: sorted[i], score: score})
+		}
+	}
+	sort.SliceStable(results, func(a, b int) bool { return results[a].score > results[b].score })
+
+	found := make([]todo_utils.Task, len(results))
+	for i, r := range results {
+		found[i] = r.task
+	}
+	return found
+}
+
+// editDistance is the Levenshtein distance between a and b, in runes
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// renderSearchResults searches every task of the user and lists the best matches,
+// numbered like the todo list. The numbers replace the user's TaskIDMap, so
+// !todo-update and !todo-delete work on them. It is shared by !todo-search and /todo search.
+func renderSearchResults(ctx context.Context, userID string, query string) (string, error) {
+	tasks, err := fetchAllTasks(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	found := searchTasks(tasks, query)
+	if len(found) == 0 {
+		return fmt.Sprintf("🔎 Nothing matches **%s**. Try fewer or different words, or `%stodo-list` to see everything.", query, commandRouter.Prefix), nil
+	}
+
+	message := fmt.Sprintf("**🔎 Search results for \"%s\"**\n\n", query)
+	taskIDMap := make(map[int]string)
+	now := time.Now()
+	for i, task := range found {
+		if i == searchResultLimit {
+			break
+		}
+		taskIDMap[i+1] = task.ID
+		message += taskLine(userID, i+1, &task, now)
+	}
+	rememberTaskNumbers(userID, taskIDMap)
+
+	if len(found) > searchResultLimit {
+		message += fmt.Sprintf("\nShowing the best %d of %d matches\n", searchResultLimit, len(found))
+	}
+	message += fmt.Sprintf("\nUse `%stodo-update <number>` / `%stodo-delete <number>` with these numbers to modify tasks", commandRouter.Prefix, commandRouter.Prefix)
+	return message, nil
+}
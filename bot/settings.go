@@ -0,0 +1,67 @@
+package bot
+
+import (
+	"time"
+)
+
+// settingsTTL is how long a user's settings are kept without being used
+const settingsTTL = 365 * 24 * time.Hour
+
+// UserSettings holds a user's preferences
+type UserSettings struct {
+	// Timezone is an IANA zone name. Empty means defaultLocation.
+	Timezone string
+	Digest   DigestSettings
+}
+
+// DigestSettings is a user's daily digest subscription
+type DigestSettings struct {
+	Enabled bool
+	// Time is when the digest is sent, as HH:MM in the user's timezone
+	Time string
+	// Focus adds an LLM written "focus for today" paragraph
+	Focus bool
+	// LastSent is the day (2006-01-02, user's timezone) the last digest was sent
+	LastSent string
+}
+
+// userSettings stores the preferences of every user who changed one
+var userSettings StateStore[UserSettings]
+
+// defaultLocation is the timezone used for users who haven't picked one.
+// Set from the config on Start.
+var defaultLocation = time.UTC
+
+// getUserSettings returns the user's settings, or the defaults if they have none
+func getUserSettings(userID string) UserSettings {
+	settings, exists := userSettings.Get(userID)
+	if exists {
+		// Still in use, keep them around
+		userSettings.Touch(userID)
+	}
+	return settings
+}
+
+// updateUserSettings applies change to the user's settings and saves them
+func updateUserSettings(userID string, change func(settings *UserSettings)) {
+	settings, _ := userSettings.Get(userID)
+	change(&settings)
+	userSettings.Set(userID, settings, settingsTTL)
+}
+
+// Location returns the user's timezone, or defaultLocation if they haven't picked one
+func (u UserSettings) Location() *time.Location {
+	if u.Timezone == "" {
+		return defaultLocation
+	}
+	loc, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		return defaultLocation
+	}
+	return loc
+}
+
+// userLocation returns the timezone due dates are read and shown in for the user
+func userLocation(userID string) *time.Location {
+	return getUserSettings(userID).Location()
+}
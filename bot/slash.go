@@ -0,0 +1,615 @@
+package bot
+
+import (
+	todo_utils "Discord_bot_v1/todo-utils"
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// minTaskNumber is the lowest value accepted for integer task number options
+var minTaskNumber = 1.0
+
+// statusChoices are the task statuses users can pick from in slash commands
+var statusChoices = []*discordgo.ApplicationCommandOptionChoice{
+	{Name: "backlog", Value: "backlog"},
+	{Name: "in-progress", Value: "in-progress"},
+	{Name: "done", Value: "done"},
+}
+
+// priorityChoices are the task priorities users can pick from in slash commands
+var priorityChoices = []*discordgo.ApplicationCommandOptionChoice{
+	{Name: "low", Value: "low"},
+	{Name: "normal", Value: "normal"},
+	{Name: "high", Value: "high"},
+	{Name: "urgent", Value: "urgent"},
+}
+
+// sortChoices are the orders /todo list can be sorted in
+var sortChoices = []*discordgo.ApplicationCommandOptionChoice{
+	{Name: "due date", Value: "due"},
+	{Name: "priority", Value: "priority"},
+	{Name: "newest first", Value: "created"},
+	{Name: "title", Value: "title"},
+}
+
+// slashCommands is the full set of application commands the bot registers on startup.
+// Anything registered on Discord that is not in this list gets removed.
+var slashCommands = []*discordgo.ApplicationCommand{
+	{
+		Name:        "todo",
+		Description: "Manage your tasks",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "create",
+				Description: "Create a new task",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "title",
+						Description: "Title of the task, #tags in it become tags",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "status",
+						Description: "Status of the task",
+						Required:    true,
+						Choices:     statusChoices,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "description",
+						Description: "Longer description of the task",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "due",
+						Description: "Due date, e.g. 2026-10-20, tomorrow 9am, besok, next friday",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "priority",
+						Description: "Priority of the task (default normal)",
+						Choices:     priorityChoices,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "View your tasks",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "page",
+						Description: "Page to show",
+						MinValue:    &minTaskNumber,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "status",
+						Description: "Only show tasks with this status",
+						Choices:     statusChoices,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "priority",
+						Description: "Only show tasks with this priority",
+						Choices:     priorityChoices,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "tag",
+						Description: "Only show tasks with this tag, e.g. frontend",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "sort",
+						Description: "Order of the list (default due date)",
+						Choices:     sortChoices,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "search",
+				Description: "Search your tasks by title, tag and description",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "query",
+						Description: "What to look for, typos are fine",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "tags",
+				Description: "List the tags on your tasks",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "undo",
+				Description: "Undo your latest task update or deletion",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "update",
+				Description: "Update a task",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "number",
+						Description: "Task number from /todo list or /todo search",
+						MinValue:    &minTaskNumber,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "task",
+						Description: "Handle like T-3, part of the title or the ID, instead of a number",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "title",
+						Description: "New title (leave empty to keep the current one)",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "status",
+						Description: "New status (leave empty to keep the current one)",
+						Choices:     statusChoices,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "description",
+						Description: "New description (leave empty to keep the current one)",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "due",
+						Description: "New due date, or \"none\" to remove it (leave empty to keep the current one)",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "priority",
+						Description: "New priority (leave empty to keep the current one)",
+						Choices:     priorityChoices,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "delete",
+				Description: "Delete a task",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "number",
+						Description: "Task number from /todo list or /todo search",
+						MinValue:    &minTaskNumber,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "task",
+						Description: "Handle like T-3, part of the title or the ID, instead of a number",
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:        "summarize",
+		Description: "Summarize a long piece of text",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "text",
+				Description: "Text to summarize",
+				Required:    true,
+			},
+		},
+	},
+	{
+		Name:        "summarize-link",
+		Description: "Summarize the content of a webpage",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "url",
+				Description: "URL of the webpage",
+				Required:    true,
+			},
+		},
+	},
+}
+
+// slashHandlers maps a top level slash command name to its handler
+var slashHandlers = map[string]func(s *discordgo.Session, i *discordgo.InteractionCreate){
+	"todo":           handleTodoSlash,
+	"summarize":      handleSummarizeSlash,
+	"summarize-link": handleSummarizeLinkSlash,
+}
+
+// registerSlashCommands makes the global commands registered on Discord match slashCommands.
+// Stale commands are deleted first, then every command is (re)created.
+func registerSlashCommands(s *discordgo.Session) error {
+	appID := s.State.User.ID
+
+	wanted := make(map[string]bool, len(slashCommands))
+	for _, cmd := range slashCommands {
+		wanted[cmd.Name] = true
+	}
+
+	// 1. Clean up commands that are no longer defined in code
+	existing, err := s.ApplicationCommands(appID, "")
+	if err != nil {
+		return fmt.Errorf("failed to list registered commands: %w", err)
+	}
+	for _, cmd := range existing {
+		if wanted[cmd.Name] {
+			continue
+		}
+		if err := s.ApplicationCommandDelete(appID, "", cmd.ID); err != nil {
+			log.Printf("Failed to delete stale command /%s: %v", cmd.Name, err)
+			continue
+		}
+		log.Printf("Deleted stale command /%s", cmd.Name)
+	}
+
+	// 2. Create (or overwrite) every command we know about
+	for _, cmd := range slashCommands {
+		if _, err := s.ApplicationCommandCreate(appID, "", cmd); err != nil {
+			return fmt.Errorf("failed to create command /%s: %w", cmd.Name, err)
+		}
+	}
+
+	log.Printf("Registered %d slash commands", len(slashCommands))
+	return nil
+}
+
+// optionMap indexes interaction options by name for easier lookup
+func optionMap(options []*discordgo.ApplicationCommandInteractionDataOption) map[string]*discordgo.ApplicationCommandInteractionDataOption {
+	m := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		m[opt.Name] = opt
+	}
+	return m
+}
+
+// respondEphemeral replies to an interaction with a message only the user can see
+func respondEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	respondEphemeralData(s, i, &discordgo.InteractionResponseData{Content: content})
+}
+
+// respondEphemeralData replies to an interaction with a message only the user can see.
+// A deferred slash command gets its placeholder replaced, which is ephemeral already;
+// a deferred component or modal keeps its message and gets a follow-up instead.
+func respondEphemeralData(s *discordgo.Session, i *discordgo.InteractionCreate, data *discordgo.InteractionResponseData) {
+	var err error
+	switch {
+	case !isDeferred(i):
+		data.Flags = discordgo.MessageFlagsEphemeral
+		err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: data,
+		})
+	case i.Type == discordgo.InteractionApplicationCommand:
+		err = editResponse(s, i, data)
+	default:
+		_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content:    data.Content,
+			Embeds:     data.Embeds,
+			Components: data.Components,
+			Flags:      discordgo.MessageFlagsEphemeral,
+		})
+	}
+	if err != nil {
+		log.Printf("Failed to respond to interaction: %v", err)
+	}
+}
+
+// updateMessage replaces the message a component or modal belongs to,
+// or the placeholder of a deferred slash command
+func updateMessage(s *discordgo.Session, i *discordgo.InteractionCreate, data *discordgo.InteractionResponseData) error {
+	if isDeferred(i) {
+		return editResponse(s, i, data)
+	}
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: data,
+	})
+}
+
+// editResponse edits the response of a deferred interaction to show data
+func editResponse(s *discordgo.Session, i *discordgo.InteractionCreate, data *discordgo.InteractionResponseData) error {
+	edit := &discordgo.WebhookEdit{Content: &data.Content}
+	if data.Embeds != nil {
+		edit.Embeds = &data.Embeds
+	}
+	if data.Components != nil {
+		edit.Components = &data.Components
+	}
+	_, err := s.InteractionResponseEdit(i.Interaction, edit)
+	return err
+}
+
+// deferredInteractions holds the IDs of interactions answered with a deferred response,
+// so the respond helpers above edit that response instead of answering again
+var deferredInteractions sync.Map
+
+// deferInteraction acknowledges an interaction before slow backend calls, so it doesn't
+// miss Discord's 3 second window. Slash commands show an ephemeral "thinking...",
+// components and modals keep their message as is until it is updated.
+// The returned func must be called once the interaction has been answered.
+func deferInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) func() {
+	response := &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredMessageUpdate}
+	if i.Type == discordgo.InteractionApplicationCommand {
+		response = &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+		}
+	}
+	if err := s.InteractionRespond(i.Interaction, response); err != nil {
+		log.Printf("Failed to defer interaction: %v", err)
+		return func() {}
+	}
+	deferredInteractions.Store(i.ID, true)
+	return func() { deferredInteractions.Delete(i.ID) }
+}
+
+// isDeferred reports whether deferInteraction acknowledged the interaction
+func isDeferred(i *discordgo.InteractionCreate) bool {
+	_, deferred := deferredInteractions.Load(i.ID)
+	return deferred
+}
+
+// editDeferred replaces the "thinking..." placeholder of a deferred interaction
+func editDeferred(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content}); err != nil {
+		log.Printf("Failed to edit interaction response: %v", err)
+	}
+}
+
+// handleTodoSlash dispatches the /todo subcommands
+func handleTodoSlash(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID := interactionUserID(i)
+	if userID == "" {
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	if len(data.Options) == 0 {
+		return
+	}
+	sub := data.Options[0]
+
+	// Every subcommand calls the backend, which can take longer than the interaction window
+	defer deferInteraction(s, i)()
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	opts := optionMap(sub.Options)
+
+	switch sub.Name {
+	case "create":
+		input := todo_utils.TaskInput{
+			Title:    opts["title"].StringValue(),
+			Status:   opts["status"].StringValue(),
+			Priority: todo_utils.DefaultPriority,
+		}
+		if opt, ok := opts["priority"]; ok {
+			input.Priority = opt.StringValue()
+		}
+		if opt, ok := opts["description"]; ok {
+			input.Description = opt.StringValue()
+		}
+		if opt, ok := opts["due"]; ok {
+			due, err := parseDueDate(userID, opt.StringValue())
+			if err != nil {
+				respondEphemeral(s, i, fmt.Sprintf("❌ %v", err))
+				return
+			}
+			if due != dueDateNone {
+				input.DueDate = due
+			}
+		}
+
+		task, err := TodoApp.CreateTask(ctx, input, userID)
+		if err != nil {
+			respondEphemeral(s, i, todoErrorMessage(err))
+			return
+		}
+		respondEphemeralEmbed(s, i, taskEmbed("✅ Task Created", task, embedColorCreated))
+
+	case "list":
+		page := 1
+		if opt, ok := opts["page"]; ok {
+			page = int(opt.IntValue())
+		}
+		query := todo_utils.TaskQuery{}
+		if opt, ok := opts["status"]; ok {
+			query.Status = opt.StringValue()
+		}
+		if opt, ok := opts["priority"]; ok {
+			query.Priority = opt.StringValue()
+		}
+		if opt, ok := opts["tag"]; ok {
+			tag, ok := todo_utils.NormalizeTag(opt.StringValue())
+			if !ok {
+				respondEphemeral(s, i, "❌ That's not a tag, tags are letters, digits, - and _ only.")
+				return
+			}
+			query.Tag = tag
+		}
+		if opt, ok := opts["sort"]; ok {
+			query.Sort = opt.StringValue()
+		}
+
+		message, actions, err := renderTaskList(ctx, userID, page, query)
+		if err != nil {
+			respondEphemeral(s, i, todoErrorMessage(err))
+			return
+		}
+		respondEphemeralData(s, i, &discordgo.InteractionResponseData{Content: message, Components: actions})
+
+	case "search":
+		message, err := renderSearchResults(ctx, userID, opts["query"].StringValue())
+		if err != nil {
+			respondEphemeral(s, i, todoErrorMessage(err))
+			return
+		}
+		respondEphemeral(s, i, message)
+
+	case "tags":
+		message, err := renderTagList(ctx, userID)
+		if err != nil {
+			respondEphemeral(s, i, todoErrorMessage(err))
+			return
+		}
+		respondEphemeral(s, i, message)
+
+	case "undo":
+		tasks, undone, err := undoChange(ctx, userID, "")
+		if err != nil {
+			respondEphemeral(s, i, undoErrorMessage(err))
+			return
+		}
+		respondEphemeralEmbed(s, i, undoEmbed(undone, tasks))
+
+	case "update":
+		taskID, ok := slashTaskID(ctx, s, i, userID, opts)
+		if !ok {
+			return
+		}
+
+		changes := todo_utils.TaskInput{}
+		if opt, ok := opts["title"]; ok {
+			changes.Title = opt.StringValue()
+		}
+		if opt, ok := opts["status"]; ok {
+			changes.Status = opt.StringValue()
+		}
+		if opt, ok := opts["description"]; ok {
+			changes.Description = opt.StringValue()
+		}
+		if opt, ok := opts["priority"]; ok {
+			changes.Priority = opt.StringValue()
+		}
+		if opt, ok := opts["due"]; ok {
+			due, err := parseDueDate(userID, opt.StringValue())
+			if err != nil {
+				respondEphemeral(s, i, fmt.Sprintf("❌ %v", err))
+				return
+			}
+			changes.DueDate = due
+		}
+
+		// Options left out keep the task's current value
+		task, undoID, err := updateTaskKeepingCurrent(ctx, userID, taskID, changes)
+		if err != nil {
+			respondEphemeral(s, i, todoErrorMessage(err))
+			return
+		}
+		respondEphemeralEmbed(s, i, taskEmbed("✅ Task Updated", task, embedColorUpdated), undoComponents(undoID)...)
+
+	case "delete":
+		taskID, ok := slashTaskID(ctx, s, i, userID, opts)
+		if !ok {
+			return
+		}
+
+		// Fetch the task first so the confirmation can say what was deleted
+		task, err := TodoApp.GetTask(ctx, taskID, userID)
+		if err != nil {
+			respondEphemeral(s, i, todoErrorMessage(err))
+			return
+		}
+		undoID, err := deleteTaskWithUndo(ctx, userID, task)
+		if err != nil {
+			respondEphemeral(s, i, todoErrorMessage(err))
+			return
+		}
+		respondEphemeralEmbed(s, i, taskEmbed("🗑️ Task Deleted", task, embedColorDeleted), undoComponents(undoID)...)
+	}
+}
+
+// lookupTaskID resolves a friendly task number from the user's last list to a task ID
+func lookupTaskID(userID string, number int) (string, bool) {
+	paginationState, exists := userPagination.Get(userID)
+	if !exists || paginationState.TaskIDMap == nil {
+		return "", false
+	}
+	taskID, ok := paginationState.TaskIDMap[number]
+	if ok {
+		// The list is still being used, keep it around
+		userPagination.Touch(userID)
+	}
+	return taskID, ok
+}
+
+// handleSummarizeSlash summarizes the given text. The LLM call can take longer than
+// the 3 second interaction window, so the response is deferred first.
+func handleSummarizeSlash(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	text := optionMap(i.ApplicationCommandData().Options)["text"].StringValue()
+	if llmService == nil {
+		respondEphemeral(s, i, llmOffMessage)
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), llmTimeout)
+	defer cancel()
+
+	summary, err := llmService.SummarizeFromText(ctx, text)
+	if err != nil {
+		log.Printf("Error getting summary: %v", err)
+		editDeferred(s, i, "Maaf, terjadi kesalahan saat meringkas teks.")
+		return
+	}
+	editDeferred(s, i, truncate(summary, 2000))
+}
+
+// handleSummarizeLinkSlash reads a webpage and summarizes its content
+func handleSummarizeLinkSlash(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	url := optionMap(i.ApplicationCommandData().Options)["url"].StringValue()
+	if llmService == nil {
+		respondEphemeral(s, i, llmOffMessage)
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	pageContent, err := llmService.ReadWebPages(url)
+	if err != nil {
+		log.Printf("Error reading webpage: %v", err)
+		editDeferred(s, i, "Maaf, gagal mengakses URL tersebut.")
+		return
+	}
+
+	if pageContent == "" {
+		editDeferred(s, i, "Halaman web tersebut tidak memiliki konten yang bisa dibaca.")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), llmTimeout)
+	defer cancel()
+
+	summary, err := llmService.SummarizeFromText(ctx, pageContent)
+	if err != nil {
+		log.Printf("Error from LLM service on webpage content: %v", err)
+		editDeferred(s, i, "Maaf, terjadi kesalahan saat meringkas konten halaman web.")
+		return
+	}
+
+	editDeferred(s, i, truncate("**Berikut ringkasan dari halaman web:**\n"+summary, 2000))
+}
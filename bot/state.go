@@ -0,0 +1,235 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// conversationTTL is how long a wizard may sit idle before it is cancelled, unless it sets its own Timeout
+	conversationTTL = 10 * time.Minute
+	// paginationTTL is how long a user's last task list (and its TaskIDMap) is remembered
+	paginationTTL = 24 * time.Hour
+	// stateSweepInterval is how often expired states are swept
+	stateSweepInterval = time.Minute
+)
+
+// StateStore holds per-user state that expires after a TTL.
+// Implementations must be safe for concurrent use, since discordgo runs handlers concurrently.
+// Values are stored and returned by copy, so callers must Set again after changing a state.
+type StateStore[T any] interface {
+	// Get returns the user's state, or false if there is none or it has expired
+	Get(userID string) (T, bool)
+	// Set stores the user's state; it expires after ttl of inactivity
+	Set(userID string, state T, ttl time.Duration)
+	// Delete removes the user's state without firing the expiry callback
+	Delete(userID string)
+	// Touch pushes back the expiry of the user's state by its ttl, and reports whether it exists
+	Touch(userID string) bool
+	// Each calls fn for every state that hasn't expired. fn runs outside the store's lock.
+	Each(fn func(userID string, state T))
+}
+
+// stateEntry is a stored state together with its expiry
+type stateEntry[T any] struct {
+	state     T
+	ttl       time.Duration
+	expiresAt time.Time
+}
+
+// MemoryStore is a mutex protected, in-memory StateStore
+type MemoryStore[T any] struct {
+	mu      sync.Mutex
+	entries map[string]*stateEntry[T]
+	// onExpire is called (outside the lock) for every state that expires
+	onExpire func(userID string, state T)
+}
+
+// NewMemoryStore creates an empty store. onExpire may be nil.
+func NewMemoryStore[T any](onExpire func(userID string, state T)) *MemoryStore[T] {
+	return &MemoryStore[T]{
+		entries:  make(map[string]*stateEntry[T]),
+		onExpire: onExpire,
+	}
+}
+
+func (m *MemoryStore[T]) Get(userID string) (T, bool) {
+	m.mu.Lock()
+	entry, exists := m.entries[userID]
+	if exists && time.Now().After(entry.expiresAt) {
+		// Expired but not swept yet, treat it as gone
+		delete(m.entries, userID)
+		m.mu.Unlock()
+		m.expire(userID, entry.state)
+		var zero T
+		return zero, false
+	}
+	m.mu.Unlock()
+
+	if !exists {
+		var zero T
+		return zero, false
+	}
+	return entry.state, true
+}
+
+func (m *MemoryStore[T]) Set(userID string, state T, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[userID] = &stateEntry[T]{
+		state:     state,
+		ttl:       ttl,
+		expiresAt: time.Now().Add(ttl),
+	}
+}
+
+func (m *MemoryStore[T]) Delete(userID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, userID)
+}
+
+func (m *MemoryStore[T]) Touch(userID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, exists := m.entries[userID]
+	if !exists {
+		return false
+	}
+	entry.expiresAt = time.Now().Add(entry.ttl)
+	return true
+}
+
+func (m *MemoryStore[T]) Each(fn func(userID string, state T)) {
+	m.mu.Lock()
+	now := time.Now()
+	states := make(map[string]T, len(m.entries))
+	for userID, entry := range m.entries {
+		if !now.After(entry.expiresAt) {
+			states[userID] = entry.state
+		}
+	}
+	m.mu.Unlock()
+
+	for userID, state := range states {
+		fn(userID, state)
+	}
+}
+
+// restore puts back a state loaded from persistent storage with its original expiry
+func (m *MemoryStore[T]) restore(userID string, state T, ttl time.Duration, expiresAt time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[userID] = &stateEntry[T]{
+		state:     state,
+		ttl:       ttl,
+		expiresAt: expiresAt,
+	}
+}
+
+// entry returns a state together with its ttl and expiry
+func (m *MemoryStore[T]) entry(userID string) (T, time.Duration, time.Time, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, exists := m.entries[userID]
+	if !exists {
+		var zero T
+		return zero, 0, time.Time{}, false
+	}
+	return entry.state, entry.ttl, entry.expiresAt, true
+}
+
+// Sweep removes expired states every interval until stop is closed
+func (m *MemoryStore[T]) Sweep(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			m.sweepExpired(now)
+		}
+	}
+}
+
+// sweepExpired removes every state that expired before now
+func (m *MemoryStore[T]) sweepExpired(now time.Time) {
+	expired := make(map[string]T)
+
+	m.mu.Lock()
+	for userID, entry := range m.entries {
+		if now.After(entry.expiresAt) {
+			expired[userID] = entry.state
+			delete(m.entries, userID)
+		}
+	}
+	m.mu.Unlock()
+
+	for userID, state := range expired {
+		m.expire(userID, state)
+	}
+}
+
+func (m *MemoryStore[T]) expire(userID string, state T) {
+	if m.onExpire != nil {
+		m.onExpire(userID, state)
+	}
+}
+
+// userStates stores ongoing conversations per user
+var userStates StateStore[ConversationState]
+
+// userPagination stores pagination state for todo lists per user
+var userPagination StateStore[PaginationState]
+
+// initStateStores sets up the in-memory state stores and starts their sweepers.
+// Expired conversations are cancelled and the user is told so in DM.
+func initStateStores(s *discordgo.Session, stop <-chan struct{}) {
+	conversations := NewMemoryStore(func(userID string, state ConversationState) {
+		conversationExpired(s, userID, state)
+	})
+	pagination := NewMemoryStore[PaginationState](nil)
+	settings := NewMemoryStore[UserSettings](nil)
+	handles := NewMemoryStore[TaskHandles](nil)
+	undo := NewMemoryStore[UndoHistory](nil)
+	drafts := NewMemoryStore[Draft](nil)
+	agents := NewMemoryStore[AgentSession](nil)
+
+	go conversations.Sweep(stateSweepInterval, stop)
+	go pagination.Sweep(stateSweepInterval, stop)
+	go settings.Sweep(stateSweepInterval, stop)
+	go handles.Sweep(stateSweepInterval, stop)
+	go undo.Sweep(stateSweepInterval, stop)
+	go drafts.Sweep(stateSweepInterval, stop)
+	go agents.Sweep(stateSweepInterval, stop)
+
+	userStates = conversations
+	userPagination = pagination
+	userSettings = settings
+	userHandles = handles
+	userUndo = undo
+	userDrafts = drafts
+	userAgents = agents
+}
+
+// conversationExpired tells the user their flow timed out
+func conversationExpired(s *discordgo.Session, userID string, state ConversationState) {
+	log.Printf("Conversation %q for user %s expired at step %d", state.Action, userID, state.Step)
+
+	dmChannel, err := s.UserChannelCreate(userID)
+	if err != nil {
+		log.Printf("Failed to create DM channel for user %s: %v", userID, err)
+		return
+	}
+	title := "conversation"
+	if w, ok := wizards[state.Action]; ok {
+		title = w.Title
+	}
+	s.ChannelMessageSend(dmChannel.ID, fmt.Sprintf("⌛ Your %s was cancelled because you didn't reply in time. Run the command again to start over.", title))
+}
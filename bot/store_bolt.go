@@ -0,0 +1,249 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	bolt "go.etcd.io/bbolt"
+)
+
+// stateDBFile is the name of the BoltDB file inside the data dir
+const stateDBFile = "state.db"
+
+// Bucket names inside the state database
+const (
+	conversationBucket = "conversations"
+	paginationBucket   = "pagination"
+	settingsBucket     = "settings"
+	reminderBucket     = "reminders"
+	handlesBucket      = "handles"
+	undoBucket         = "undo"
+	draftsBucket       = "drafts"
+	agentBucket        = "agent"
+)
+
+// boltRecord is how a single state is written to BoltDB
+type boltRecord[T any] struct {
+	State     T             `json:"state"`
+	TTL       time.Duration `json:"ttl"`
+	ExpiresAt time.Time     `json:"expires_at"`
+}
+
+// BoltStore is a StateStore that keeps states in memory and writes every change
+// through to a BoltDB bucket, so they survive restarts.
+// Reads are served from memory only; the bucket is read once when the store is opened.
+type BoltStore[T any] struct {
+	*MemoryStore[T]
+	db     *bolt.DB
+	bucket []byte
+}
+
+// NewBoltStore opens (or creates) the bucket and loads every stored state into memory.
+// States that expired while the bot was offline are swept on the first sweep,
+// which fires onExpire for them as usual.
+func NewBoltStore[T any](db *bolt.DB, bucket string, onExpire func(userID string, state T)) (*BoltStore[T], error) {
+	b := &BoltStore[T]{db: db, bucket: []byte(bucket)}
+
+	b.MemoryStore = NewMemoryStore(func(userID string, state T) {
+		b.remove(userID)
+		if onExpire != nil {
+			onExpire(userID, state)
+		}
+	})
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		bkt, err := tx.CreateBucketIfNotExists(b.bucket)
+		if err != nil {
+			return err
+		}
+
+		return bkt.ForEach(func(k, v []byte) error {
+			var record boltRecord[T]
+			if err := json.Unmarshal(v, &record); err != nil {
+				log.Printf("Skipping unreadable %s state for user %s: %v", bucket, k, err)
+				return nil
+			}
+			b.MemoryStore.restore(string(k), record.State, record.TTL, record.ExpiresAt)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s bucket: %w", bucket, err)
+	}
+
+	return b, nil
+}
+
+func (b *BoltStore[T]) Set(userID string, state T, ttl time.Duration) {
+	b.MemoryStore.Set(userID, state, ttl)
+	b.write(userID, boltRecord[T]{State: state, TTL: ttl, ExpiresAt: time.Now().Add(ttl)})
+}
+
+func (b *BoltStore[T]) Delete(userID string) {
+	b.MemoryStore.Delete(userID)
+	b.remove(userID)
+}
+
+func (b *BoltStore[T]) Touch(userID string) bool {
+	if !b.MemoryStore.Touch(userID) {
+		return false
+	}
+
+	state, ttl, expiresAt, ok := b.MemoryStore.entry(userID)
+	if ok {
+		b.write(userID, boltRecord[T]{State: state, TTL: ttl, ExpiresAt: expiresAt})
+	}
+	return true
+}
+
+// write stores a record. Failures are logged, the in-memory state stays authoritative.
+func (b *BoltStore[T]) write(userID string, record boltRecord[T]) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		log.Printf("Failed to encode %s state for user %s: %v", b.bucket, userID, err)
+		return
+	}
+
+	err = b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(b.bucket).Put([]byte(userID), data)
+	})
+	if err != nil {
+		log.Printf("Failed to persist %s state for user %s: %v", b.bucket, userID, err)
+	}
+}
+
+// remove deletes a record
+func (b *BoltStore[T]) remove(userID string) {
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(b.bucket).Delete([]byte(userID))
+	})
+	if err != nil {
+		log.Printf("Failed to remove %s state for user %s: %v", b.bucket, userID, err)
+	}
+}
+
+// openStateDB opens the state database inside dataDir, creating the dir if needed
+func openStateDB(dataDir string) (*bolt.DB, error) {
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create data dir: %w", err)
+	}
+
+	db, err := bolt.Open(filepath.Join(dataDir, stateDBFile), 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state database: %w", err)
+	}
+	return db, nil
+}
+
+// initPersistentStateStores sets up BoltDB backed state stores and starts their sweepers
+func initPersistentStateStores(s *discordgo.Session, db *bolt.DB, stop <-chan struct{}) error {
+	conversations, err := NewBoltStore(db, conversationBucket, func(userID string, state ConversationState) {
+		conversationExpired(s, userID, state)
+	})
+	if err != nil {
+		return err
+	}
+
+	pagination, err := NewBoltStore[PaginationState](db, paginationBucket, nil)
+	if err != nil {
+		return err
+	}
+
+	settings, err := NewBoltStore[UserSettings](db, settingsBucket, nil)
+	if err != nil {
+		return err
+	}
+
+	handles, err := NewBoltStore[TaskHandles](db, handlesBucket, nil)
+	if err != nil {
+		return err
+	}
+
+	undo, err := NewBoltStore[UndoHistory](db, undoBucket, nil)
+	if err != nil {
+		return err
+	}
+
+	drafts, err := NewBoltStore[Draft](db, draftsBucket, nil)
+	if err != nil {
+		return err
+	}
+
+	agents, err := NewBoltStore[AgentSession](db, agentBucket, nil)
+	if err != nil {
+		return err
+	}
+
+	go conversations.Sweep(stateSweepInterval, stop)
+	go pagination.Sweep(stateSweepInterval, stop)
+	go settings.Sweep(stateSweepInterval, stop)
+	go handles.Sweep(stateSweepInterval, stop)
+	go undo.Sweep(stateSweepInterval, stop)
+	go drafts.Sweep(stateSweepInterval, stop)
+	go agents.Sweep(stateSweepInterval, stop)
+
+	userStates = conversations
+	userPagination = pagination
+	userSettings = settings
+	userHandles = handles
+	userUndo = undo
+	userDrafts = drafts
+	userAgents = agents
+	return nil
+}
+
+// BoltReminderStore is a ReminderStore backed by a BoltDB bucket
+type BoltReminderStore struct {
+	db     *bolt.DB
+	bucket []byte
+}
+
+// NewBoltReminderStore opens (or creates) the reminders bucket
+func NewBoltReminderStore(db *bolt.DB) (*BoltReminderStore, error) {
+	store := &BoltReminderStore{db: db, bucket: []byte(reminderBucket)}
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(store.bucket)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s bucket: %w", reminderBucket, err)
+	}
+	return store, nil
+}
+
+func (b *BoltReminderStore) All() ([]Reminder, error) {
+	var all []Reminder
+	err := b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(b.bucket).ForEach(func(k, v []byte) error {
+			var r Reminder
+			if err := json.Unmarshal(v, &r); err != nil {
+				log.Printf("Skipping unreadable reminder for task %s: %v", k, err)
+				return nil
+			}
+			all = append(all, r)
+			return nil
+		})
+	})
+	return all, err
+}
+
+func (b *BoltReminderStore) Put(r Reminder) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(b.bucket).Put([]byte(r.TaskID), data)
+	})
+}
+
+func (b *BoltReminderStore) Delete(taskID string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(b.bucket).Delete([]byte(taskID))
+	})
+}
@@ -0,0 +1,72 @@
+package bot
+
+import (
+	todo_utils "Discord_bot_v1/todo-utils"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// tagCount is a tag together with the number of tasks carrying it
+type tagCount struct {
+	Tag   string
+	Count int
+}
+
+// countTags counts how many tasks carry each tag, most used first, then by name
+func countTags(tasks []todo_utils.Task) []tagCount {
+	counts := make(map[string]int)
+	for _, task := range tasks {
+		for _, tag := range task.Tags {
+			counts[tag]++
+		}
+	}
+
+	list := make([]tagCount, 0, len(counts))
+	for tag, count := range counts {
+		list = append(list, tagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(list, func(a, b int) bool {
+		if list[a].Count != list[b].Count {
+			return list[a].Count > list[b].Count
+		}
+		return list[a].Tag < list[b].Tag
+	})
+	return list
+}
+
+// renderTagList fetches every task of the user and lists their tags with counts.
+// It is shared by !todo-tags and /todo tags.
+func renderTagList(ctx context.Context, userID string) (string, error) {
+	tasks, err := fetchAllTasks(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	counts := countTags(tasks)
+	if len(counts) == 0 {
+		return "🏷️ None of your tasks have tags yet. Add some by putting `#tags` in a title, e.g. `Fix the login page #frontend`.", nil
+	}
+
+	var b strings.Builder
+	b.WriteString("**🏷️ Your tags**\n\n")
+	for _, c := range counts {
+		task := "tasks"
+		if c.Count == 1 {
+			task = "task"
+		}
+		fmt.Fprintf(&b, "`#%s` · %d %s\n", c.Tag, c.Count, task)
+	}
+	fmt.Fprintf(&b, "\nUse `%stodo-list #<tag>` to see the tasks with a tag", commandRouter.Prefix)
+	return b.String(), nil
+}
+
+// tagBadges renders tags as inline code badges, e.g. "`#frontend` `#bug`"
+func tagBadges(tags []string) string {
+	badges := make([]string, len(tags))
+	for i, tag := range tags {
+		badges[i] = "`#" + tag + "`"
+	}
+	return strings.Join(badges, " ")
+}
@@ -0,0 +1,349 @@
+package bot
+
+import (
+	todo_utils "Discord_bot_v1/todo-utils"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// taskListPageSize is how many tasks are shown per page of the todo list.
+// Set from the config on Start.
+var taskListPageSize = 5
+
+// Fetching every task of a user is done in pages of fetchAllPageSize, up to fetchAllMaxPages
+const (
+	fetchAllPageSize = 50
+	fetchAllMaxPages = 20
+)
+
+// statusEmoji returns the emoji shown next to a task with the given status
+func statusEmoji(status string) string {
+	switch status {
+	case "done":
+		return "✅"
+	case "in-progress":
+		return "🔄"
+	case "backlog":
+		return "📥"
+	}
+	return "📝"
+}
+
+// errTaskListCutOff is returned by fetchCompleteTasks for users with more tasks than are fetched
+var errTaskListCutOff = fmt.Errorf("task list cut off after %d tasks", fetchAllPageSize*fetchAllMaxPages)
+
+// fetchAllTasks fetches every task of the user, page by page
+func fetchAllTasks(ctx context.Context, userID string) ([]todo_utils.Task, error) {
+	tasks, _, err := fetchTasks(ctx, userID, todo_utils.TaskQuery{})
+	return tasks, err
+}
+
+// fetchCompleteTasks is fetchAllTasks for callers that drop whatever is missing from the
+// list, it fails with errTaskListCutOff rather than return part of it
+func fetchCompleteTasks(ctx context.Context, userID string) ([]todo_utils.Task, error) {
+	tasks, complete, err := fetchTasks(ctx, userID, todo_utils.TaskQuery{})
+	if err == nil && !complete {
+		return nil, errTaskListCutOff
+	}
+	return tasks, err
+}
+
+// fetchTasks fetches every task of the user matching the query, page by page.
+// The query is passed on to the backend, but not applied here.
+// complete is false when the list was cut off after fetchAllMaxPages.
+func fetchTasks(ctx context.Context, userID string, query todo_utils.TaskQuery) (tasks []todo_utils.Task, complete bool, err error) {
+	for page := 1; page <= fetchAllMaxPages; page++ {
+		taskResponse, err := TodoApp.GetTasks(ctx, userID, page, fetchAllPageSize, query)
+		if err != nil {
+			return nil, false, err
+		}
+		tasks = append(tasks, taskResponse.Tasks...)
+
+		if len(taskResponse.Tasks) == 0 || page >= taskResponse.TotalPages {
+			return tasks, true, nil
+		}
+	}
+	return tasks, false, nil
+}
+
+// renderTaskList fetches the user's tasks matching the query, sorts them, refreshes
+// the TaskIDMap for the given page and builds the list message together with its
+// navigation, "New task", per-task edit buttons and the quick action menu.
+// It is shared by !todo-list, /todo list and the pagination buttons.
+func renderTaskList(ctx context.Context, userID string, page int, query todo_utils.TaskQuery) (string, []discordgo.MessageComponent, error) {
+	// Not every backend can filter and sort, so fetch everything, apply the query here and page ourselves
+	tasks, complete, err := fetchTasks(ctx, userID, query)
+	if err != nil {
+		return "", nil, err
+	}
+	if query.IsZero() && complete {
+		// We have the full list anyway, pick up changes made outside the bot.
+		// A list that was cut off would drop the handles and reminders of the tasks past it.
+		syncHandles(userID, tasks)
+		if reminders != nil {
+			reminders.Sync(userID, tasks)
+		}
+	}
+	handles := assignHandles(userID, tasks)
+	tasks = filterTasks(tasks, query)
+
+	if len(tasks) == 0 {
+		message := fmt.Sprintf("📭 You have no tasks yet. Use `%stodo-create` to add some!", commandRouter.Prefix)
+		if !query.IsZero() {
+			message = fmt.Sprintf("📭 No tasks match 🔎 %s. Run `%stodo-list` without arguments to see all of them.", describeTaskQuery(query), commandRouter.Prefix)
+		}
+		// Remember the query so actions taken from here come back to the same list
+		userPagination.Set(userID, PaginationState{Page: 1, Query: query, TaskIDMap: map[int]string{}}, paginationTTL)
+		return message, []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{newTaskButton()}},
+		}, nil
+	}
+
+	sortTasks(tasks, query.Sort)
+
+	// The page may have emptied out, e.g. after deleting its last task
+	totalPages := (len(tasks) + taskListPageSize - 1) / taskListPageSize
+	page = min(max(page, 1), totalPages)
+	pageTasks := tasks[(page-1)*taskListPageSize : min(page*taskListPageSize, len(tasks))]
+	now := time.Now()
+
+	// Build a fresh task ID map for this page
+	taskIDMap := make(map[int]string)
+
+	// Build the task list message
+	editButtons := []discordgo.MessageComponent{}
+	actionOptions := []discordgo.SelectMenuOption{}
+	message := fmt.Sprintf("**📋 Your Todo List (Page %d/%d)**\n", page, totalPages)
+	if !query.IsZero() {
+		message += fmt.Sprintf("🔎 %s\n", describeTaskQuery(query))
+	}
+	message += "\n"
+
+	for i, task := range pageTasks {
+		// Calculate the friendly number for this task
+		friendlyNumber := (i + 1) + ((page - 1) * taskListPageSize)
+
+		// Store the mapping between friendly number and actual task ID
+		taskIDMap[friendlyNumber] = task.ID
+
+		message += taskLine(userID, friendlyNumber, &task, now)
+
+		// One edit button per task, carrying the real task ID
+		editButtons = append(editButtons, discordgo.Button{
+			Label:    fmt.Sprintf("✏️ %d", friendlyNumber),
+			Style:    discordgo.SecondaryButton,
+			CustomID: "todo_edit_" + task.ID,
+		})
+
+		actionOptions = append(actionOptions, taskActionOptions(friendlyNumber, handles[task.ID], task)...)
+	}
+
+	userPagination.Set(userID, PaginationState{Page: page, Query: query, TaskIDMap: taskIDMap}, paginationTTL)
+
+	message += fmt.Sprintf("\n📄 Page %d of %d | Total tasks: %d\n", page, totalPages, len(tasks))
+	message += fmt.Sprintf("Use the buttons below, or `%[1]stodo-update <number|T-n>` / `%[1]stodo-delete <number|T-n>` to modify tasks\n", commandRouter.Prefix)
+
+	// Add navigation buttons
+	components := []discordgo.MessageComponent{}
+
+	// Show previous button unless we're on the first page
+	if page > 1 {
+		components = append(components, discordgo.Button{
+			Label:    "⬅️ Previous",
+			Style:    discordgo.PrimaryButton,
+			CustomID: pageButtonID("prev", page-1, query),
+		})
+	}
+
+	// Show next button unless we're on the last page
+	if page < totalPages {
+		components = append(components, discordgo.Button{
+			Label:    "Next ➡️",
+			Style:    discordgo.PrimaryButton,
+			CustomID: pageButtonID("next", page+1, query),
+		})
+	}
+
+	components = append(components, newTaskButton())
+
+	actions := []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: components},
+		discordgo.ActionsRow{Components: editButtons},
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{
+				MenuType:    discordgo.StringSelectMenu,
+				CustomID:    "todo_action",
+				Placeholder: "⚡ Quick actions…",
+				Options:     actionOptions,
+			},
+		}},
+	}
+
+	return message, actions, nil
+}
+
+// taskLine renders one numbered task of a list with its handle, and its description on a second line
+func taskLine(userID string, number int, task *todo_utils.Task, now time.Time) string {
+	line := fmt.Sprintf("`%d.` %s %s **%s** (%s)",
+		number,
+		handleLabel(userID, task),
+		statusEmoji(task.Status),
+		task.Title,
+		task.Status)
+	if emoji := priorityEmoji(task.EffectivePriority()); emoji != "" {
+		line += fmt.Sprintf(" %s %s", emoji, task.EffectivePriority())
+	}
+	if due := dueDateLabel(userID, task, now); due != "" {
+		line += " · " + due
+	}
+	if len(task.Tags) > 0 {
+		line += " " + tagBadges(task.Tags)
+	}
+	line += "\n"
+	if task.Description != "" {
+		line += fmt.Sprintf("      _%s_\n", truncate(task.Description, 80))
+	}
+	return line
+}
+
+// Quick actions offered for every task in the list
+const (
+	taskActionDone   = "done"
+	taskActionStart  = "start"
+	taskActionReopen = "reopen"
+	taskActionDelete = "delete"
+)
+
+// taskActionOptions builds the quick action select options for a task.
+// Option values look like <action>_<taskID>, so they don't depend on the TaskIDMap.
+func taskActionOptions(friendlyNumber int, handle string, task todo_utils.Task) []discordgo.SelectMenuOption {
+	options := []discordgo.SelectMenuOption{}
+	label := strings.TrimSpace(fmt.Sprintf("%d. %s %s", friendlyNumber, handle, truncate(task.Title, 60)))
+
+	if task.Status != "done" {
+		options = append(options, discordgo.SelectMenuOption{
+			Label: label, Description: "Mark done", Value: taskActionDone + "_" + task.ID,
+			Emoji: &discordgo.ComponentEmoji{Name: "✅"},
+		})
+	}
+	if task.Status != "in-progress" {
+		options = append(options, discordgo.SelectMenuOption{
+			Label: label, Description: "Start", Value: taskActionStart + "_" + task.ID,
+			Emoji: &discordgo.ComponentEmoji{Name: "🔄"},
+		})
+	}
+	if task.Status != "backlog" {
+		options = append(options, discordgo.SelectMenuOption{
+			Label: label, Description: "Move back to backlog", Value: taskActionReopen + "_" + task.ID,
+			Emoji: &discordgo.ComponentEmoji{Name: "📥"},
+		})
+	}
+	options = append(options, discordgo.SelectMenuOption{
+		Label: label, Description: "Delete", Value: taskActionDelete + "_" + task.ID,
+		Emoji: &discordgo.ComponentEmoji{Name: "🗑️"},
+	})
+
+	return options
+}
+
+// pageButtonID builds the custom ID of a pagination button, todo_<direction>_<page>[_<query>].
+// The query is carried along so Next and Previous keep the filter.
+func pageButtonID(direction string, page int, query todo_utils.TaskQuery) string {
+	customID := fmt.Sprintf("todo_%s_%d", direction, page)
+	if !query.IsZero() {
+		customID += "_" + encodeTaskQuery(query)
+	}
+	return customID
+}
+
+// currentPage returns the page the user was last on, or 1
+func currentPage(userID string) int {
+	if state, exists := userPagination.Get(userID); exists && state.Page > 0 {
+		return state.Page
+	}
+	return 1
+}
+
+// rememberTaskNumbers makes the numbers of a list other than the todo list, e.g. search
+// results, the ones friendly numbers resolve to. The page and query of the last todo list are kept.
+func rememberTaskNumbers(userID string, taskIDMap map[int]string) {
+	state, _ := userPagination.Get(userID)
+	if state.Page == 0 {
+		state.Page = 1
+	}
+	state.TaskIDMap = taskIDMap
+	userPagination.Set(userID, state, paginationTTL)
+}
+
+// currentQuery returns the filter and sort order of the user's last list
+func currentQuery(userID string) todo_utils.TaskQuery {
+	if state, exists := userPagination.Get(userID); exists {
+		return state.Query
+	}
+	return todo_utils.TaskQuery{}
+}
+
+// newTaskButton opens the task creation modal
+func newTaskButton() discordgo.Button {
+	return discordgo.Button{
+		Label:    "➕ New task",
+		Style:    discordgo.SuccessButton,
+		CustomID: "todo_new",
+	}
+}
+
+// updateTaskKeepingCurrent updates a task, filling every field passed as empty
+// with its current value so that skipped fields are never blanked out.
+// A due date of dueDateNone removes it. It returns the updated task and the ID of its undo entry.
+func updateTaskKeepingCurrent(ctx context.Context, userID string, taskID string, changes todo_utils.TaskInput) (*todo_utils.Task, string, error) {
+	task, err := TodoApp.GetTask(ctx, taskID, userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	input := task.Input()
+	if changes.Title != "" {
+		input.Title = changes.Title
+	}
+	if changes.Status != "" {
+		input.Status = changes.Status
+	}
+	if changes.Description != "" {
+		input.Description = changes.Description
+	}
+	if changes.Priority != "" {
+		input.Priority = changes.Priority
+	}
+	if changes.DueDate == dueDateNone {
+		input.DueDate = ""
+	} else if changes.DueDate != "" {
+		input.DueDate = changes.DueDate
+	}
+
+	return updateTaskWithUndo(ctx, userID, task, input)
+}
+
+// truncate shortens s to at most n runes, adding an ellipsis if it was cut
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// interactionUserID returns the ID of the user behind an interaction.
+// For DM interactions, i.Member is nil, so we fall back to i.User.
+func interactionUserID(i *discordgo.InteractionCreate) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
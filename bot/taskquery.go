@@ -0,0 +1,158 @@
+package bot
+
+import (
+	todo_utils "Discord_bot_v1/todo-utils"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// taskSorts are the orders the todo list can be sorted in. The first one is the default.
+var taskSorts = []string{"due", "priority", "created", "title"}
+
+// taskQueryUsage explains the !todo-list arguments
+const taskQueryUsage = "use `status:<backlog|in-progress|done>`, `priority:<low|normal|high|urgent>`, `#<tag>` or `sort:<due|priority|created|title>`"
+
+// parseTaskQuery parses list arguments like "status:in-progress priority:high #frontend sort:due".
+// A tag can be given as #frontend or tag:frontend. Pairs may be separated by spaces or commas, so the same format works inside custom IDs.
+func parseTaskQuery(raw string) (todo_utils.TaskQuery, error) {
+	query := todo_utils.TaskQuery{}
+	tokens := strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+
+	for _, token := range tokens {
+		if strings.HasPrefix(token, "#") {
+			token = "tag:" + token[1:]
+		}
+		key, value, ok := strings.Cut(strings.ToLower(token), ":")
+		if !ok || value == "" {
+			return query, fmt.Errorf("I don't understand %q, %s", token, taskQueryUsage)
+		}
+
+		switch key {
+		case "status":
+			status, err := validateStatus(value)
+			if err != nil {
+				return query, err
+			}
+			query.Status = status
+		case "priority", "prio":
+			priority, err := validatePriority(value)
+			if err != nil {
+				return query, err
+			}
+			query.Priority = priority
+		case "tag":
+			tag, ok := todo_utils.NormalizeTag(value)
+			if !ok {
+				return query, fmt.Errorf("%q is not a tag, tags are letters, digits, - and _ only", value)
+			}
+			if query.Tag != "" && query.Tag != tag {
+				return query, errors.New("you can filter by one tag at a time")
+			}
+			query.Tag = tag
+		case "sort":
+			if !containsString(taskSorts, value) {
+				return query, fmt.Errorf("sort must be one of %s", strings.Join(taskSorts, ", "))
+			}
+			query.Sort = value
+		default:
+			return query, fmt.Errorf("I don't understand %q, %s", token, taskQueryUsage)
+		}
+	}
+	return query, nil
+}
+
+// parseTaskQueryArgs is the Command.Parse wrapper around parseTaskQuery
+func parseTaskQueryArgs(raw string) (interface{}, error) {
+	return parseTaskQuery(raw)
+}
+
+// encodeTaskQuery writes a query in the format parseTaskQuery reads, comma separated
+// so it fits in a component custom ID
+func encodeTaskQuery(query todo_utils.TaskQuery) string {
+	pairs := []string{}
+	if query.Status != "" {
+		pairs = append(pairs, "status:"+query.Status)
+	}
+	if query.Priority != "" {
+		pairs = append(pairs, "priority:"+query.Priority)
+	}
+	if query.Tag != "" {
+		pairs = append(pairs, "tag:"+query.Tag)
+	}
+	if query.Sort != "" {
+		pairs = append(pairs, "sort:"+query.Sort)
+	}
+	return strings.Join(pairs, ",")
+}
+
+// describeTaskQuery is the filter line shown above a filtered list
+func describeTaskQuery(query todo_utils.TaskQuery) string {
+	return strings.ReplaceAll(encodeTaskQuery(query), ",", " · ")
+}
+
+// filterTasks keeps the tasks matching the query. The backend may ignore the
+// filter parameters, so this is always applied on our side as well.
+func filterTasks(tasks []todo_utils.Task, query todo_utils.TaskQuery) []todo_utils.Task {
+	filtered := tasks[:0:0]
+	for i := range tasks {
+		if query.Matches(&tasks[i]) {
+			filtered = append(filtered, tasks[i])
+		}
+	}
+	return filtered
+}
+
+// sortTasks orders tasks by one of taskSorts; an empty order means the default.
+// Ties keep the due date order.
+func sortTasks(tasks []todo_utils.Task, order string) {
+	sortByDueDate(tasks)
+
+	switch order {
+	case "priority":
+		// Most important first
+		sort.SliceStable(tasks, func(a, b int) bool {
+			return todo_utils.PriorityRank(tasks[a].EffectivePriority()) > todo_utils.PriorityRank(tasks[b].EffectivePriority())
+		})
+	case "created":
+		// Newest first, tasks without a readable creation time go last
+		sort.SliceStable(tasks, func(a, b int) bool {
+			createdA, errA := time.Parse(time.RFC3339, tasks[a].CreatedAt)
+			createdB, errB := time.Parse(time.RFC3339, tasks[b].CreatedAt)
+			if (errA == nil) != (errB == nil) {
+				return errA == nil
+			}
+			return errA == nil && createdA.After(createdB)
+		})
+	case "title":
+		sort.SliceStable(tasks, func(a, b int) bool {
+			return strings.ToLower(tasks[a].Title) < strings.ToLower(tasks[b].Title)
+		})
+	}
+}
+
+// priorityEmoji returns the marker shown next to a task's priority, empty for the default one
+func priorityEmoji(priority string) string {
+	switch priority {
+	case "low":
+		return "🔽"
+	case "high":
+		return "🔼"
+	case "urgent":
+		return "🔥"
+	}
+	return ""
+}
+
+// containsString reports whether list contains value
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
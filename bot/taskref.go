@@ -0,0 +1,274 @@
+package bot
+
+import (
+	todo_utils "Discord_bot_v1/todo-utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// minIDPrefix is the shortest start of a task ID that is looked up as an ID
+const minIDPrefix = 4
+
+// maxPickOptions is how many tasks the disambiguation menu offers, Discord's limit for a select menu
+const maxPickOptions = 25
+
+// taskRef is how a user points at a task in !todo-update and !todo-delete:
+// a number from their last list, a handle like T-42, a task ID (or its start) or a piece of its title
+type taskRef struct {
+	// Number is the friendly number from the last list, 0 when not used
+	Number int
+	// Handle is the number of a T-<number> handle, 0 when not used
+	Handle int
+	Text   string
+	// Quoted is set when Text was typed in quotes, it is then only matched against titles
+	Quoted bool
+}
+
+// quotePairs are the quotes a title fragment can be wrapped in, including the curly ones phones type
+var quotePairs = [][2]string{{`"`, `"`}, {"'", "'"}, {"“", "”"}, {"‘", "’"}}
+
+// parseTaskRef parses the optional task argument of !todo-update and !todo-delete.
+// No argument yields nil so the handler can show the task list instead.
+func parseTaskRef(raw string) (interface{}, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	if number, err := strconv.Atoi(raw); err == nil {
+		if number < 1 {
+			return nil, errors.New("please provide a valid task number")
+		}
+		return taskRef{Number: number}, nil
+	}
+	if handle, ok := parseHandle(raw); ok {
+		return taskRef{Handle: handle}, nil
+	}
+
+	for _, quotes := range quotePairs {
+		if len(raw) > len(quotes[0])+len(quotes[1]) && strings.HasPrefix(raw, quotes[0]) && strings.HasSuffix(raw, quotes[1]) {
+			text := strings.TrimSpace(raw[len(quotes[0]) : len(raw)-len(quotes[1])])
+			if text == "" {
+				break
+			}
+			return taskRef{Text: text, Quoted: true}, nil
+		}
+	}
+	return taskRef{Text: raw}, nil
+}
+
+// findTasksByText returns the tasks a text reference points at. Unquoted text is first
+// tried as a task ID or the start of one; after that it is matched as part of a title,
+// and if no title contains it, as a fuzzy search.
+func findTasksByText(ctx context.Context, userID string, ref taskRef) ([]todo_utils.Task, error) {
+	tasks, err := fetchAllTasks(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ref.Quoted && !strings.ContainsAny(ref.Text, " \t") {
+		if found := tasksByID(tasks, ref.Text); len(found) > 0 {
+			return found, nil
+		}
+	}
+
+	sortByDueDate(tasks)
+	fragment := strings.ToLower(ref.Text)
+	var found []todo_utils.Task
+	for _, task := range tasks {
+		if strings.Contains(strings.ToLower(task.Title), fragment) {
+			found = append(found, task)
+		}
+	}
+	if len(found) > 0 {
+		return found, nil
+	}
+	return searchTasks(tasks, ref.Text), nil
+}
+
+// tasksByID returns the task with exactly this ID, or else every task whose ID starts with it
+func tasksByID(tasks []todo_utils.Task, id string) []todo_utils.Task {
+	id = strings.ToLower(id)
+	var found []todo_utils.Task
+	for _, task := range tasks {
+		taskID := strings.ToLower(task.ID)
+		if taskID == id {
+			return []todo_utils.Task{task}
+		}
+		if len(id) >= minIDPrefix && strings.HasPrefix(taskID, id) {
+			found = append(found, task)
+		}
+	}
+	return found
+}
+
+// startTaskWizard starts the update or delete wizard on the task in ctx.Args.
+// When a title fragment matches several tasks, the user picks one from a menu first.
+func startTaskWizard(ctx *CommandContext, action string) {
+	userID := ctx.Message.Author.ID
+	ref := ctx.Args.(taskRef)
+
+	if ref.Number > 0 {
+		taskID, ok := resolveTaskNumber(ctx, ref.Number)
+		if !ok {
+			return
+		}
+		startWizard(ctx.Session, userID, ctx.DM(), action, map[string]string{"task_id": taskID})
+		return
+	}
+
+	if ref.Handle > 0 {
+		taskID, ok := lookupHandle(userID, ref.Handle)
+		if !ok {
+			ctx.SendDM(unknownHandleMessage(ref.Handle))
+			return
+		}
+		startWizard(ctx.Session, userID, ctx.DM(), action, map[string]string{"task_id": taskID})
+		return
+	}
+
+	found, err := findTasksByText(ctx.Ctx, userID, ref)
+	if err != nil {
+		ctx.SendDM(todoErrorMessage(err))
+		return
+	}
+
+	switch len(found) {
+	case 0:
+		ctx.SendDM(fmt.Sprintf("❌ None of your tasks match **%s**. Try `%stodo-search` or `%stodo-list` to find it.", ref.Text, commandRouter.Prefix, commandRouter.Prefix))
+	case 1:
+		ctx.SendDM(fmt.Sprintf("%s Found **%s**", statusEmoji(found[0].Status), found[0].Title))
+		startWizard(ctx.Session, userID, ctx.DM(), action, map[string]string{"task_id": found[0].ID})
+	default:
+		ctx.Session.ChannelMessageSendComplex(ctx.DM(), &discordgo.MessageSend{
+			Content:    fmt.Sprintf("🔎 %d tasks match **%s**, which one do you want to %s?", len(found), ref.Text, action),
+			Components: taskPickMenu(userID, action, found),
+		})
+	}
+}
+
+// taskPickMenu builds the select menu to pick one of several matching tasks.
+// Its custom ID is todo_pick_<action>, the option values are task IDs.
+func taskPickMenu(userID string, action string, tasks []todo_utils.Task) []discordgo.MessageComponent {
+	now := time.Now()
+	options := []discordgo.SelectMenuOption{}
+	for i, task := range tasks {
+		if i == maxPickOptions {
+			break
+		}
+		description := task.Status
+		if due, ok := task.Due(); ok {
+			description += " · due " + formatDueDate(userID, due)
+			if isOverdue(&task, now) {
+				description += " (overdue)"
+			}
+		}
+		options = append(options, discordgo.SelectMenuOption{
+			Label:       truncate(taskHandle(userID, &task)+" "+task.Title, 100),
+			Description: description,
+			Value:       task.ID,
+			Emoji:       &discordgo.ComponentEmoji{Name: statusEmoji(task.Status)},
+		})
+	}
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{
+				MenuType:    discordgo.StringSelectMenu,
+				CustomID:    "todo_pick_" + action,
+				Placeholder: "Pick a task…",
+				Options:     options,
+			},
+		}},
+	}
+}
+
+// pickTask starts the wizard on the task picked from a taskPickMenu
+func pickTask(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, userID string, action string, taskID string) {
+	if action != "update" && action != "delete" {
+		return
+	}
+
+	// Make sure it still exists and belongs to the user
+	task, err := TodoApp.GetTask(ctx, taskID, userID)
+	if err != nil {
+		respondEphemeral(s, i, todoErrorMessage(err))
+		return
+	}
+
+	err = updateMessage(s, i, &discordgo.InteractionResponseData{
+		Content:    fmt.Sprintf("%s Picked **%s**", statusEmoji(task.Status), task.Title),
+		Components: []discordgo.MessageComponent{},
+	})
+	if err != nil {
+		log.Printf("Failed to update task picker: %v", err)
+	}
+	// Wizards run in DMs, even if the menu was somehow used elsewhere
+	startWizard(s, userID, dmChannelID(s, userID, i.ChannelID), action, map[string]string{"task_id": task.ID})
+}
+
+// slashTaskID resolves the number or task option of /todo update and /todo delete.
+// When it can't narrow them down to exactly one task it tells the user why, or lets them
+// pick from a menu, and reports false.
+func slashTaskID(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, userID string, opts map[string]*discordgo.ApplicationCommandInteractionDataOption) (string, bool) {
+	if opt, ok := opts["number"]; ok {
+		taskID, ok := lookupTaskID(userID, int(opt.IntValue()))
+		if !ok {
+			respondEphemeral(s, i, "❌ Task not found. Please run `/todo list` to see the current task numbers.")
+		}
+		return taskID, ok
+	}
+
+	text := ""
+	if opt, ok := opts["task"]; ok {
+		text = strings.TrimSpace(opt.StringValue())
+	}
+	if text == "" {
+		respondEphemeral(s, i, "❌ Tell me which task, either by `number` from `/todo list` or by `task` title or ID.")
+		return "", false
+	}
+
+	// Handles and quotes work like in !todo-update, but a bare number here is a title or ID, not a list number
+	ref := taskRef{Text: text}
+	if parsed, _ := parseTaskRef(text); parsed != nil && parsed.(taskRef).Number == 0 {
+		ref = parsed.(taskRef)
+	}
+	if ref.Handle > 0 {
+		taskID, ok := lookupHandle(userID, ref.Handle)
+		if !ok {
+			respondEphemeral(s, i, unknownHandleMessage(ref.Handle))
+		}
+		return taskID, ok
+	}
+
+	found, err := findTasksByText(ctx, userID, ref)
+	if err != nil {
+		respondEphemeral(s, i, todoErrorMessage(err))
+		return "", false
+	}
+	switch len(found) {
+	case 0:
+		respondEphemeral(s, i, fmt.Sprintf("❌ None of your tasks match **%s**.", ref.Text))
+		return "", false
+	case 1:
+		return found[0].ID, true
+	}
+
+	// Same menu as the prefix commands, picking one carries on in the wizard
+	action := i.ApplicationCommandData().Options[0].Name
+	respondEphemeralData(s, i, &discordgo.InteractionResponseData{
+		Content:    fmt.Sprintf("🔎 %d tasks match **%s**, which one do you want to %s?", len(found), ref.Text, action),
+		Components: taskPickMenu(userID, action, found),
+	})
+	return "", false
+}
+
+// unknownHandleMessage tells the user a handle doesn't point at any of their tasks
+func unknownHandleMessage(handle int) string {
+	return fmt.Sprintf("❌ None of your tasks is %s, it may have been deleted. Run `%stodo-list` to see the current handles.", formatHandle(handle), commandRouter.Prefix)
+}
@@ -0,0 +1,435 @@
+package bot
+
+import (
+	todo_utils "Discord_bot_v1/todo-utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// undoHistorySize is how many changes are remembered per user
+	undoHistorySize = 10
+	// undoHistoryTTL is how long a user's changes can be undone with !todo-undo
+	undoHistoryTTL = 24 * time.Hour
+	// undoWindow is how long the Undo button on a confirmation works
+	undoWindow = 5 * time.Minute
+)
+
+// What an UndoEntry reverts
+const (
+	undoActionUpdate = "update"
+	undoActionDelete = "delete"
+	// undoActionBulk reverts the Items of a bulk operation together
+	undoActionBulk = "bulk"
+)
+
+// embedColorRestored is the color of the confirmation after an undo
+const embedColorRestored = 0x9b59b6
+
+// errNothingToUndo is returned by undoChange when there is no change to revert
+var errNothingToUndo = errors.New("nothing to undo")
+
+// UndoEntry is a change made to a task together with the task as it was before
+type UndoEntry struct {
+	// ID identifies the entry in Undo button custom IDs
+	ID string
+	// Action is undoActionUpdate, undoActionDelete or undoActionBulk
+	Action string
+	// Items are the changes of a bulk operation, only set for undoActionBulk
+	Items []UndoEntry
+	// Before is the task as it was before the change
+	Before todo_utils.Task
+	// Handle is the number of the task's handle, so a deleted task gets it back
+	Handle int
+	At     time.Time
+}
+
+// UndoHistory holds a user's latest changes, oldest first
+type UndoHistory struct {
+	Entries []UndoEntry
+}
+
+// userUndo stores the undo history of every user
+var userUndo StateStore[UndoHistory]
+
+// undoMu serialises changes to undo histories
+var undoMu sync.Mutex
+
+// newUndoEntry describes a change about to be made to before.
+// It must be called before a delete, while the task still has its handle.
+func newUndoEntry(userID string, action string, before *todo_utils.Task) UndoEntry {
+	return UndoEntry{
+		ID:     strconv.FormatInt(time.Now().UnixNano(), 36),
+		Action: action,
+		Before: *before,
+		Handle: handleNumber(userID, before.ID),
+		At:     time.Now(),
+	}
+}
+
+// saveUndo adds an entry to the user's history, dropping the oldest past undoHistorySize
+func saveUndo(userID string, entry UndoEntry) {
+	undoMu.Lock()
+	defer undoMu.Unlock()
+
+	history, _ := userUndo.Get(userID)
+	history.Entries = append(history.Entries, entry)
+	if len(history.Entries) > undoHistorySize {
+		history.Entries = history.Entries[len(history.Entries)-undoHistorySize:]
+	}
+	userUndo.Set(userID, history, undoHistoryTTL)
+}
+
+// undoBusy holds the IDs of entries being undone right now, so a double click can't revert
+// the same change twice. Guarded by undoMu.
+var undoBusy = map[string]bool{}
+
+// claimUndo finds an entry of the user's history and marks it busy, leaving it in place.
+// An empty entryID picks the latest one not already being undone.
+// The entry must be handed back with releaseUndo.
+func claimUndo(userID string, entryID string) (UndoEntry, bool) {
+	undoMu.Lock()
+	defer undoMu.Unlock()
+
+	history, exists := userUndo.Get(userID)
+	if !exists {
+		return UndoEntry{}, false
+	}
+	for i := len(history.Entries) - 1; i >= 0; i-- {
+		entry := history.Entries[i]
+		if undoBusy[entry.ID] || (entryID != "" && entry.ID != entryID) {
+			continue
+		}
+		undoBusy[entry.ID] = true
+		return entry, true
+	}
+	return UndoEntry{}, false
+}
+
+// releaseUndo ends a claim on an entry. With drop set the entry is removed from the
+// user's history, otherwise it stays where it was so the user can try again.
+func releaseUndo(userID string, entryID string, drop bool) {
+	undoMu.Lock()
+	defer undoMu.Unlock()
+
+	delete(undoBusy, entryID)
+	if !drop {
+		return
+	}
+	history, exists := userUndo.Get(userID)
+	if !exists {
+		return
+	}
+	for i, entry := range history.Entries {
+		if entry.ID == entryID {
+			history.Entries = append(history.Entries[:i], history.Entries[i+1:]...)
+			userUndo.Set(userID, history, undoHistoryTTL)
+			return
+		}
+	}
+}
+
+// renameInUndo points the user's remaining entries for oldID at a restored task's newID
+func renameInUndo(userID string, oldID string, newID string) {
+	undoMu.Lock()
+	defer undoMu.Unlock()
+
+	history, exists := userUndo.Get(userID)
+	if !exists {
+		return
+	}
+	for i := range history.Entries {
+		renameEntry(&history.Entries[i], oldID, newID)
+	}
+	userUndo.Set(userID, history, undoHistoryTTL)
+}
+
+// renameEntry points an entry, or the items of a bulk entry, at a restored task's newID
+func renameEntry(entry *UndoEntry, oldID string, newID string) {
+	if entry.Before.ID == oldID {
+		entry.Before.ID = newID
+	}
+	for i := range entry.Items {
+		renameEntry(&entry.Items[i], oldID, newID)
+	}
+}
+
+// replaceUndoItems sets the items of a bulk entry that are left to undo
+func replaceUndoItems(userID string, entryID string, items []UndoEntry) {
+	undoMu.Lock()
+	defer undoMu.Unlock()
+
+	history, exists := userUndo.Get(userID)
+	if !exists {
+		return
+	}
+	for i := range history.Entries {
+		if history.Entries[i].ID == entryID {
+			history.Entries[i].Items = items
+			userUndo.Set(userID, history, undoHistoryTTL)
+			return
+		}
+	}
+}
+
+// updateTaskWithUndo replaces before with input and remembers before so the change can be undone.
+// It returns the updated task and the ID of its undo entry.
+func updateTaskWithUndo(ctx context.Context, userID string, before *todo_utils.Task, input todo_utils.TaskInput) (*todo_utils.Task, string, error) {
+	entry := newUndoEntry(userID, undoActionUpdate, before)
+	task, err := TodoApp.UpdateTask(ctx, before.ID, input, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	saveUndo(userID, entry)
+	return task, entry.ID, nil
+}
+
+// deleteTaskWithUndo deletes the task and remembers it so it can be restored.
+// It returns the ID of its undo entry.
+func deleteTaskWithUndo(ctx context.Context, userID string, task *todo_utils.Task) (string, error) {
+	entry := newUndoEntry(userID, undoActionDelete, task)
+	if _, err := TodoApp.DeleteTask(ctx, task.ID, userID); err != nil {
+		return "", err
+	}
+	saveUndo(userID, entry)
+	return entry.ID, nil
+}
+
+// undoChange reverts an entry of the user's history, the latest one if entryID is empty.
+// An update is reverted by writing the old task back, a delete by creating it again,
+// with its old handle. It returns the restored tasks and the entry that was undone.
+//
+// A bulk entry reverts what it can. Changes that may still work on another try stay in
+// the entry; it only fails when none of its changes could be undone.
+func undoChange(ctx context.Context, userID string, entryID string) ([]*todo_utils.Task, UndoEntry, error) {
+	entry, ok := claimUndo(userID, entryID)
+	if !ok {
+		return nil, UndoEntry{}, errNothingToUndo
+	}
+
+	if entry.Action == undoActionBulk {
+		tasks, left, err := undoBulk(userID, entry.Items)
+		if len(left) > 0 {
+			replaceUndoItems(userID, entry.ID, left)
+		}
+		releaseUndo(userID, entry.ID, len(left) == 0)
+		return tasks, entry, err
+	}
+
+	task, err := undoItem(ctx, userID, entry)
+	if err != nil {
+		releaseUndo(userID, entry.ID, !undoRetryable(err))
+		return nil, entry, err
+	}
+	releaseUndo(userID, entry.ID, true)
+	return []*todo_utils.Task{task}, entry, nil
+}
+
+// undoItem reverts a single update or delete
+func undoItem(ctx context.Context, userID string, entry UndoEntry) (*todo_utils.Task, error) {
+	switch entry.Action {
+	case undoActionUpdate:
+		return TodoApp.UpdateTask(ctx, entry.Before.ID, entry.Before.Input(), userID)
+	case undoActionDelete:
+		task, err := TodoApp.CreateTask(ctx, entry.Before.Input(), userID)
+		if err == nil && task.ID != "" {
+			if entry.Handle > 0 {
+				rebindHandle(userID, task.ID, entry.Handle)
+			}
+			renameInUndo(userID, entry.Before.ID, task.ID)
+		}
+		return task, err
+	}
+	return nil, errNothingToUndo
+}
+
+// undoRetryable reports whether an undo that failed with err may work on another try.
+// A task that is gone or no longer valid will never take the change back.
+func undoRetryable(err error) bool {
+	return !errors.Is(err, todo_utils.ErrNotFound) && !errors.Is(err, todo_utils.ErrValidation) && !errors.Is(err, errNothingToUndo)
+}
+
+// undoBulk reverts the items of a bulk entry, at most bulkParallelism at a time, each with
+// its own commandTimeout. It returns the restored tasks and the items worth another try.
+func undoBulk(userID string, items []UndoEntry) ([]*todo_utils.Task, []UndoEntry, error) {
+	tasks := make([]*todo_utils.Task, len(items))
+	errs := make([]error, len(items))
+	sem := make(chan struct{}, bulkParallelism)
+	var wg sync.WaitGroup
+
+	for i, item := range items {
+		wg.Add(1)
+		go func(i int, item UndoEntry) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+			defer cancel()
+			tasks[i], errs[i] = undoItem(ctx, userID, item)
+		}(i, item)
+	}
+	wg.Wait()
+
+	var restored []*todo_utils.Task
+	var left []UndoEntry
+	var firstErr error
+	for i, err := range errs {
+		if err == nil {
+			restored = append(restored, tasks[i])
+			continue
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if undoRetryable(err) {
+			left = append(left, items[i])
+		}
+	}
+	if len(restored) == 0 {
+		return nil, left, firstErr
+	}
+	return restored, left, nil
+}
+
+// undoButton is the Undo button for an entry. With list set, pressing it re-renders the todo list.
+// Custom IDs look like undo_<entryID> or undo_<entryID>_list.
+func undoButton(entryID string, list bool) discordgo.Button {
+	customID := "undo_" + entryID
+	if list {
+		customID += "_list"
+	}
+	return discordgo.Button{
+		Label:    "↩️ Undo",
+		Style:    discordgo.SecondaryButton,
+		CustomID: customID,
+	}
+}
+
+// undoComponents puts the Undo button for an entry in a row of its own
+func undoComponents(entryID string) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{undoButton(entryID, false)}},
+	}
+}
+
+// undoHeading is the title of the confirmation shown after an entry was undone
+func undoHeading(entry UndoEntry) string {
+	switch entry.Action {
+	case undoActionDelete:
+		return "↩️ Task Restored"
+	case undoActionBulk:
+		return "↩️ Bulk Change Undone"
+	}
+	return "↩️ Change Undone"
+}
+
+// undoEmbed is the confirmation shown after an entry was undone. A bulk entry lists
+// its restored tasks and tells how many are left to undo.
+func undoEmbed(entry UndoEntry, tasks []*todo_utils.Task) *discordgo.MessageEmbed {
+	if entry.Action != undoActionBulk {
+		return taskEmbed(undoHeading(entry), tasks[0], embedColorRestored)
+	}
+
+	lines := make([]string, len(tasks))
+	for i, task := range tasks {
+		lines[i] = "✅ " + truncate(task.Title, 80)
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       undoHeading(entry),
+		Description: truncate(fmt.Sprintf("Undid %d of %d changes.\n\n", len(tasks), len(entry.Items))+strings.Join(lines, "\n"), 4096),
+		Color:       embedColorRestored,
+	}
+	if len(tasks) < len(entry.Items) {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Some changes couldn't be undone. Run %stodo-undo again to retry them.", commandRouter.Prefix)}
+	}
+	return embed
+}
+
+// undoErrorMessage turns an undoChange error into a message for the user
+func undoErrorMessage(err error) string {
+	if errors.Is(err, errNothingToUndo) {
+		return fmt.Sprintf("🤷 There's nothing to undo. Only your last %d changes from the past day can be undone.", undoHistorySize)
+	}
+	return todoErrorMessage(err)
+}
+
+// handleUndoComponent handles the Undo button on a confirmation
+func handleUndoComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	parts := strings.SplitN(i.MessageComponentData().CustomID, "_", 3)
+	if len(parts) < 2 || parts[0] != "undo" {
+		return
+	}
+	entryID := parts[1]
+	fromList := len(parts) == 3 && parts[2] == "list"
+
+	userID := interactionUserID(i)
+	if userID == "" {
+		return
+	}
+
+	// Only the button's own change, and only for a short while
+	history, _ := userUndo.Get(userID)
+	var entry *UndoEntry
+	for n := range history.Entries {
+		if history.Entries[n].ID == entryID {
+			entry = &history.Entries[n]
+		}
+	}
+	if entry == nil {
+		respondEphemeral(s, i, "🤷 That change was already undone or is too old to undo.")
+		return
+	}
+	if time.Since(entry.At) > undoWindow {
+		respondEphemeral(s, i, fmt.Sprintf("⌛ This Undo button has expired. Use `%stodo-undo` to undo your latest change.", commandRouter.Prefix))
+		return
+	}
+
+	defer deferInteraction(s, i)()
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	tasks, undone, err := undoChange(ctx, userID, entryID)
+	if err != nil {
+		respondEphemeral(s, i, undoErrorMessage(err))
+		return
+	}
+
+	notice := fmt.Sprintf("↩️ Undone, **%s** is back as it was", tasks[0].Title)
+	if len(tasks) > 1 {
+		notice = fmt.Sprintf("↩️ Undone, %d tasks are back as they were", len(tasks))
+	}
+	if fromList {
+		respondWithTaskList(ctx, s, i, userID, currentPage(userID), currentQuery(userID), notice, "")
+		return
+	}
+
+	// Keep the confirmation, swap the button for the outcome
+	content := notice
+	var embeds []*discordgo.MessageEmbed
+	if i.Message != nil {
+		embeds = i.Message.Embeds
+		if i.Message.Content != "" {
+			content = i.Message.Content + "\n" + notice
+		}
+	}
+	if undone.Action != undoActionUpdate {
+		embeds = append(embeds, undoEmbed(undone, tasks))
+	}
+	err = updateMessage(s, i, &discordgo.InteractionResponseData{
+		Content:    content,
+		Embeds:     embeds,
+		Components: []discordgo.MessageComponent{},
+	})
+	if err != nil {
+		log.Printf("Failed to update undone confirmation: %v", err)
+	}
+}
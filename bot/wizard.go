@@ -0,0 +1,216 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// defaultWizardAttempts is how many invalid answers a step accepts before the wizard gives up
+const defaultWizardAttempts = 3
+
+// Keywords understood by every wizard step
+const (
+	wizardCancelKeyword = "cancel"
+	wizardBackKeyword   = "back"
+	wizardSkipKeyword   = "skip"
+)
+
+// WizardStep is a single question asked by a Wizard
+type WizardStep struct {
+	// Name is the key the answer is stored under in the wizard data
+	Name string
+	// Prompt is the question sent to the user
+	Prompt string
+	// Validate checks the answer and returns the value to store. Optional.
+	// The error is shown to the user, who can then try again.
+	// ctx.Ctx is not set yet while validating.
+	Validate func(ctx *WizardContext, input string) (string, error)
+	// Skippable lets the user type "skip" to leave the answer empty
+	Skippable bool
+}
+
+// Wizard is a declarative multi-step DM conversation.
+// Every wizard understands "cancel" and "back", gives up after MaxAttempts invalid
+// answers to a step and is cancelled after Timeout without a reply.
+type Wizard struct {
+	// Name identifies the wizard in ConversationState.Action
+	Name string
+	// Title describes the flow to the user, e.g. "task creation"
+	Title string
+	// Steps are asked in order
+	Steps []WizardStep
+	// MaxAttempts defaults to defaultWizardAttempts
+	MaxAttempts int
+	// Timeout defaults to conversationTTL
+	Timeout time.Duration
+	// Commit runs once every step has been answered. A returned error is shown to the user.
+	Commit func(ctx *WizardContext) error
+	// ErrorMessage formats a Commit error for the user. Defaults to "❌ <error>".
+	ErrorMessage func(err error) string
+}
+
+// WizardContext is passed to Wizard.Commit and to step validators
+type WizardContext struct {
+	// Ctx bounds the backend calls made by Commit
+	Ctx       context.Context
+	Session   *discordgo.Session
+	UserID    string
+	ChannelID string
+	// Data holds the answers keyed by step name, plus anything passed to startWizard
+	Data map[string]string
+}
+
+// Send sends a message to the channel the wizard is running in
+func (c *WizardContext) Send(content string) {
+	c.Session.ChannelMessageSend(c.ChannelID, content)
+}
+
+// SendEmbed sends an embed to the channel the wizard is running in,
+// optionally with components such as an Undo button
+func (c *WizardContext) SendEmbed(embed *discordgo.MessageEmbed, components ...discordgo.MessageComponent) {
+	c.Session.ChannelMessageSendComplex(c.ChannelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
+	})
+}
+
+// wizards holds every registered wizard by name
+var wizards = make(map[string]*Wizard)
+
+// registerWizard makes a wizard available to startWizard. Meant to be called from init.
+func registerWizard(w *Wizard) {
+	if _, exists := wizards[w.Name]; exists {
+		panic(fmt.Sprintf("bot: wizard %q registered twice", w.Name))
+	}
+	if w.MaxAttempts == 0 {
+		w.MaxAttempts = defaultWizardAttempts
+	}
+	if w.Timeout == 0 {
+		w.Timeout = conversationTTL
+	}
+	if w.ErrorMessage == nil {
+		w.ErrorMessage = func(err error) string { return fmt.Sprintf("❌ %v", err) }
+	}
+	wizards[w.Name] = w
+}
+
+// startWizard begins the named wizard for the user and sends its first prompt.
+// data seeds the wizard data, e.g. with the ID of the task being edited.
+func startWizard(s *discordgo.Session, userID string, channelID string, name string, data map[string]string) {
+	w := wizards[name]
+	if data == nil {
+		data = make(map[string]string)
+	}
+
+	userStates.Set(userID, ConversationState{Action: name, Data: data}, w.Timeout)
+	s.ChannelMessageSend(channelID, w.Steps[0].Prompt+"\n_Type `back` to go back or `cancel` to stop at any time._")
+}
+
+// continueWizard handles a DM reply from a user who is in the middle of a wizard.
+// state is the user's copy from userStates, so it is saved back after every change.
+func continueWizard(s *discordgo.Session, m *discordgo.MessageCreate, state *ConversationState) {
+	channelID := dmChannelID(s, m.Author.ID, m.ChannelID)
+	send := func(content string) { s.ChannelMessageSend(channelID, content) }
+
+	w, ok := wizards[state.Action]
+	if !ok || state.Step < 0 || state.Step >= len(w.Steps) {
+		// Left over from an older version of the bot
+		log.Printf("Dropping unknown conversation %q at step %d for user %s", state.Action, state.Step, m.Author.ID)
+		userStates.Delete(m.Author.ID)
+		send("⚠️ Your previous conversation could not be resumed. Please run the command again.")
+		return
+	}
+	if state.Data == nil {
+		state.Data = make(map[string]string)
+	}
+
+	input := strings.TrimSpace(m.Content)
+	step := w.Steps[state.Step]
+
+	ctx := &WizardContext{
+		Session:   s,
+		UserID:    m.Author.ID,
+		ChannelID: channelID,
+		Data:      state.Data,
+	}
+
+	switch strings.ToLower(input) {
+	case wizardCancelKeyword:
+		userStates.Delete(m.Author.ID)
+		send(fmt.Sprintf("🛑 %s cancelled.", capitalize(w.Title)))
+		return
+
+	case wizardBackKeyword:
+		if state.Step > 0 {
+			state.Step--
+			state.Attempts = 0
+			delete(state.Data, w.Steps[state.Step].Name)
+		}
+		userStates.Set(m.Author.ID, *state, w.Timeout)
+		send(w.Steps[state.Step].Prompt)
+		return
+	}
+
+	// Work out the answer for this step
+	value := input
+	if step.Skippable && strings.ToLower(input) == wizardSkipKeyword {
+		value = ""
+	} else if step.Validate != nil {
+		validated, err := step.Validate(ctx, input)
+		if err != nil {
+			state.Attempts++
+			if state.Attempts >= w.MaxAttempts {
+				userStates.Delete(m.Author.ID)
+				send(fmt.Sprintf("❌ Too many invalid attempts. %s cancelled.", capitalize(w.Title)))
+				return
+			}
+			userStates.Set(m.Author.ID, *state, w.Timeout)
+			send(fmt.Sprintf("❌ %v. Please try again. (%d/%d attempts)", err, state.Attempts, w.MaxAttempts))
+			return
+		}
+		value = validated
+	}
+
+	state.Data[step.Name] = value
+	state.Attempts = 0
+	state.Step++
+
+	// More questions to ask
+	if state.Step < len(w.Steps) {
+		userStates.Set(m.Author.ID, *state, w.Timeout)
+		send(w.Steps[state.Step].Prompt)
+		return
+	}
+
+	// All answered, end the conversation and commit
+	userStates.Delete(m.Author.ID)
+
+	commitCtx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	ctx.Ctx = commitCtx
+	if err := w.Commit(ctx); err != nil {
+		send(w.ErrorMessage(err))
+		send("Try Again")
+	}
+}
+
+// anyUser adapts a validator that doesn't depend on who is answering
+func anyUser(validate func(input string) (string, error)) func(ctx *WizardContext, input string) (string, error) {
+	return func(_ *WizardContext, input string) (string, error) {
+		return validate(input)
+	}
+}
+
+// capitalize upper-cases the first letter of s
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
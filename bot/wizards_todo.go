@@ -0,0 +1,139 @@
+package bot
+
+import (
+	todo_utils "Discord_bot_v1/todo-utils"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// taskStatuses are the statuses the todo backend accepts
+var taskStatuses = []string{"backlog", "in-progress", "done"}
+
+func init() {
+	registerWizard(&Wizard{
+		Name:  "create",
+		Title: "task creation",
+		Steps: []WizardStep{
+			{Name: "title", Prompt: "📝 Let's create a new task! What's the title?", Validate: anyUser(validateTitle)},
+			{Name: "status", Prompt: "Got it ✅ Now, what’s the status? (backlog, in-progress, done)", Validate: anyUser(validateStatus)},
+			{Name: "due", Prompt: "📅 When is it due? e.g. " + dueDateExamples + " (Type 'skip' for no due date)", Validate: validateDueDate, Skippable: true},
+			{Name: "priority", Prompt: "🚩 How important is it? (low, normal, high, urgent) (Type 'skip' for normal)", Validate: anyUser(validatePriority), Skippable: true},
+		},
+		Commit:       commitCreateTask,
+		ErrorMessage: todoErrorMessage,
+	})
+
+	registerWizard(&Wizard{
+		Name:  "update",
+		Title: "task update",
+		Steps: []WizardStep{
+			{Name: "title", Prompt: "📝 Let's update your task! What's the new title? (Type 'skip' to keep the current title)", Validate: anyUser(validateTitle), Skippable: true},
+			{Name: "status", Prompt: "Got it ✅ Now, what's the status? (backlog, in-progress, done) (Type 'skip' to keep the current status)", Validate: anyUser(validateStatus), Skippable: true},
+			{Name: "due", Prompt: "📅 When is it due? e.g. " + dueDateExamples + " (Type 'skip' to keep the current due date or 'none' to remove it)", Validate: validateDueDate, Skippable: true},
+			{Name: "priority", Prompt: "🚩 How important is it? (low, normal, high, urgent) (Type 'skip' to keep the current priority)", Validate: anyUser(validatePriority), Skippable: true},
+		},
+		Commit:       commitUpdateTask,
+		ErrorMessage: todoErrorMessage,
+	})
+
+	registerWizard(&Wizard{
+		Name:  "delete",
+		Title: "task deletion",
+		Steps: []WizardStep{
+			{Name: "confirm", Prompt: "🗑️ Are you sure you want to delete this task? Type 'yes' to confirm or 'no' to cancel."},
+		},
+		Commit:       commitDeleteTask,
+		ErrorMessage: todoErrorMessage,
+	})
+
+	registerWizard(&Wizard{
+		Name:  "bulk",
+		Title: "bulk change",
+		Steps: []WizardStep{
+			{Name: "confirm", Prompt: "⚠️ Go ahead with all of these? Type 'yes' to confirm or 'no' to cancel."},
+		},
+		Commit:       commitBulk,
+		ErrorMessage: todoErrorMessage,
+	})
+}
+
+// validateTitle rejects empty titles
+func validateTitle(input string) (string, error) {
+	if input == "" {
+		return "", errors.New("the title can't be empty")
+	}
+	return input, nil
+}
+
+// validateStatus accepts one of taskStatuses, case insensitively
+func validateStatus(input string) (string, error) {
+	status := strings.ToLower(input)
+	for _, valid := range taskStatuses {
+		if status == valid {
+			return status, nil
+		}
+	}
+	return "", fmt.Errorf("status must be one of %s", strings.Join(taskStatuses, ", "))
+}
+
+// validatePriority accepts one of todo_utils.Priorities, case insensitively
+func validatePriority(input string) (string, error) {
+	priority := strings.ToLower(input)
+	for _, valid := range todo_utils.Priorities {
+		if priority == valid {
+			return priority, nil
+		}
+	}
+	return "", fmt.Errorf("priority must be one of %s", strings.Join(todo_utils.Priorities, ", "))
+}
+
+func commitCreateTask(ctx *WizardContext) error {
+	input := todo_utils.TaskInput{Title: ctx.Data["title"], Status: ctx.Data["status"], DueDate: ctx.Data["due"], Priority: ctx.Data["priority"]}
+	if input.Priority == "" {
+		input.Priority = todo_utils.DefaultPriority
+	}
+	if input.DueDate == dueDateNone {
+		input.DueDate = ""
+	}
+
+	task, err := TodoApp.CreateTask(ctx.Ctx, input, ctx.UserID)
+	if err != nil {
+		return err
+	}
+
+	ctx.SendEmbed(taskEmbed("✅ Task Created", task, embedColorCreated))
+	return nil
+}
+
+func commitUpdateTask(ctx *WizardContext) error {
+	// Skipped steps are empty and keep the task's current value
+	changes := todo_utils.TaskInput{Title: ctx.Data["title"], Status: ctx.Data["status"], DueDate: ctx.Data["due"], Priority: ctx.Data["priority"]}
+	task, undoID, err := updateTaskKeepingCurrent(ctx.Ctx, ctx.UserID, ctx.Data["task_id"], changes)
+	if err != nil {
+		return err
+	}
+
+	ctx.SendEmbed(taskEmbed("✅ Task Updated", task, embedColorUpdated), undoComponents(undoID)...)
+	return nil
+}
+
+func commitDeleteTask(ctx *WizardContext) error {
+	if strings.ToLower(ctx.Data["confirm"]) != "yes" {
+		ctx.Send("🗑️ Task deletion cancelled.")
+		return nil
+	}
+
+	// Fetch the task first so the confirmation can say what was deleted
+	task, err := TodoApp.GetTask(ctx.Ctx, ctx.Data["task_id"], ctx.UserID)
+	if err != nil {
+		return err
+	}
+	undoID, err := deleteTaskWithUndo(ctx.Ctx, ctx.UserID, task)
+	if err != nil {
+		return err
+	}
+
+	ctx.SendEmbed(taskEmbed("🗑️ Task Deleted", task, embedColorDeleted), undoComponents(undoID)...)
+	return nil
+}
@@ -1,23 +1,467 @@
 package config
 
 import (
-	"github.com/joho/godotenv"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
+// AppConfig holds every setting of the bot.
+// Values are resolved in this order, later ones winning:
+// defaults, the YAML config file, environment variables (and .env), command line flags.
 type AppConfig struct {
-	Token string
+	Token string `yaml:"token"`
+	// DataDir is where the bot keeps its state database. Empty keeps state in memory only.
+	DataDir string `yaml:"data_dir"`
+	// BackendURL is the base URL of the todo API, including the /api prefix
+	BackendURL string `yaml:"backend_url"`
+	// CommandPrefix is what prefix commands start with, e.g. "!"
+	CommandPrefix string `yaml:"command_prefix"`
+	// PageSize is how many tasks are shown per page of the todo list
+	PageSize int `yaml:"page_size"`
+	// LogLevel is one of debug, info, warn or error
+	LogLevel string `yaml:"log_level"`
+	// Timezone is the IANA zone due dates are read in for users who haven't picked their own
+	Timezone  string          `yaml:"timezone"`
+	LLM       LLMConfig       `yaml:"llm"`
+	Timeouts  TimeoutsConfig  `yaml:"timeouts"`
+	Reminders RemindersConfig `yaml:"reminders"`
+}
+
+// LLMConfig selects and configures the LLM behind summaries, the digest focus, !todo add and !ask
+type LLMConfig struct {
+	// Provider is one of LLMProviders, LLMProviderNone runs the bot without an LLM
+	Provider string `yaml:"provider"`
+	// Model is empty for the provider's default model
+	Model string `yaml:"model"`
+	// APIKey is not needed for ollama, nor for OpenAI compatible servers that don't ask for one
+	APIKey string `yaml:"api_key"`
+	// BaseURL overrides the provider's endpoint, e.g. http://localhost:11434 for ollama
+	// or the URL of a self-hosted OpenAI compatible API. Empty uses the provider's default.
+	BaseURL string `yaml:"base_url"`
+	// Temperature is nil for the model's default
+	Temperature *float64 `yaml:"temperature"`
+	// MaxTokens caps the length of replies, 0 means no cap
+	MaxTokens int `yaml:"max_tokens"`
+}
+
+// TimeoutsConfig holds the timeouts for outgoing calls
+type TimeoutsConfig struct {
+	// Backend bounds a single request attempt to the todo API
+	Backend time.Duration `yaml:"backend"`
+	// Command bounds all backend calls made for one command or wizard reply
+	Command time.Duration `yaml:"command"`
+}
+
+// RemindersConfig controls the due date reminders sent in DM
+type RemindersConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Offsets are how long before the due date a reminder is sent, e.g. [24h, 1h]
+	Offsets []time.Duration `yaml:"offsets"`
+	// Overdue sends one more reminder once the due date has passed
+	Overdue bool `yaml:"overdue"`
+	// CheckInterval is how often reminders are checked for being due
+	CheckInterval time.Duration `yaml:"check_interval"`
+	// PollInterval is how often tasks are re-read from the backend to catch changes made elsewhere
+	PollInterval time.Duration `yaml:"poll_interval"`
+}
+
+// Defaults used when a setting is not given anywhere
+const (
+	defaultConfigFile     = "config.yaml"
+	defaultDataDir        = "data"
+	defaultBackendURL     = "http://backend:8080/api"
+	defaultCommandPrefix  = "!"
+	defaultPageSize       = 5
+	defaultLogLevel       = "info"
+	defaultTimezone       = "Asia/Jakarta"
+	defaultLLMProvider    = "gemini"
+	defaultBackendTimeout = 5 * time.Second
+	defaultCommandTimeout = 15 * time.Second
+	defaultCheckInterval  = time.Minute
+	defaultPollInterval   = 15 * time.Minute
+
+	// maxPageSize keeps a page within Discord's limit of 5 buttons per row
+	maxPageSize = 5
+)
+
+// defaultReminderOffsets remind a day and an hour before a task is due
+var defaultReminderOffsets = []time.Duration{24 * time.Hour, time.Hour}
+
+// LLMProviderNone as llm.provider turns the LLM features off
+const LLMProviderNone = "none"
+
+// LLMProviders are the supported values for llm.provider
+var LLMProviders = []string{"gemini", "openai", "ollama", LLMProviderNone}
+
+// LogLevels are the supported values for log_level
+var LogLevels = []string{"debug", "info", "warn", "error"}
+
+// defaults returns a config with every default filled in
+func defaults() *AppConfig {
+	return &AppConfig{
+		DataDir:       defaultDataDir,
+		BackendURL:    defaultBackendURL,
+		CommandPrefix: defaultCommandPrefix,
+		PageSize:      defaultPageSize,
+		LogLevel:      defaultLogLevel,
+		Timezone:      defaultTimezone,
+		LLM: LLMConfig{
+			Provider: defaultLLMProvider,
+		},
+		Timeouts: TimeoutsConfig{
+			Backend: defaultBackendTimeout,
+			Command: defaultCommandTimeout,
+		},
+		Reminders: RemindersConfig{
+			Enabled:       true,
+			Offsets:       defaultReminderOffsets,
+			Overdue:       true,
+			CheckInterval: defaultCheckInterval,
+			PollInterval:  defaultPollInterval,
+		},
+	}
+}
+
+// LoadConfig builds the configuration from the config file, environment and the
+// given command line arguments (usually os.Args[1:]), then validates it.
+// The returned error lists every missing or invalid setting.
+func LoadConfig(args []string) (*AppConfig, error) {
+	cfg := defaults()
+
+	// 1. Parse flags first, we need -config to find the file
+	flags, values := newFlagSet()
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// 2. .env is optional, real environment variables win over it
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read .env: %w", err)
+	}
+
+	// 3. Config file: an explicitly given one must exist, the default one is optional
+	configFile, explicit := defaultConfigFile, false
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		configFile, explicit = path, true
+	}
+	if values.configFile != "" {
+		configFile, explicit = values.configFile, true
+	}
+	if err := loadFile(cfg, configFile, explicit); err != nil {
+		return nil, err
+	}
+
+	// 4. Environment, then 5. flags
+	var problems []error
+	problems = append(problems, applyEnv(cfg)...)
+	applyFlags(cfg, flags, values)
+
+	problems = append(problems, cfg.Validate()...)
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(problems...))
+	}
+	return cfg, nil
 }
 
-func LoadConfig() *AppConfig {
-	err := godotenv.Load()
+// loadFile reads the YAML config file into cfg
+func loadFile(cfg *AppConfig, path string, required bool) error {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return &AppConfig{
-			"",
+		if errors.Is(err, os.ErrNotExist) && !required {
+			return nil
 		}
+		return fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	return &AppConfig{
-		Token: os.Getenv("BOT_API_TOKEN"),
+	// Unknown keys are most likely typos, so they are rejected
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv overrides cfg with every environment variable that is set
+func applyEnv(cfg *AppConfig) []error {
+	var problems []error
+
+	setString := func(key string, target *string) {
+		if value := os.Getenv(key); value != "" {
+			*target = value
+		}
+	}
+	setInt := func(key string, target *int) {
+		if value := os.Getenv(key); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				problems = append(problems, fmt.Errorf("%s: %q is not a number", key, value))
+				return
+			}
+			*target = n
+		}
+	}
+	setBool := func(key string, target *bool) {
+		if value := os.Getenv(key); value != "" {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				problems = append(problems, fmt.Errorf("%s: %q is not true or false", key, value))
+				return
+			}
+			*target = b
+		}
+	}
+	setFloat := func(key string, target **float64) {
+		if value := os.Getenv(key); value != "" {
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				problems = append(problems, fmt.Errorf("%s: %q is not a number", key, value))
+				return
+			}
+			*target = &f
+		}
+	}
+	setDurations := func(key string, target *[]time.Duration) {
+		if value := os.Getenv(key); value != "" {
+			list, err := parseDurationList(value)
+			if err != nil {
+				problems = append(problems, fmt.Errorf("%s: %w", key, err))
+				return
+			}
+			*target = list
+		}
+	}
+	setDuration := func(key string, target *time.Duration) {
+		if value := os.Getenv(key); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				problems = append(problems, fmt.Errorf("%s: %q is not a duration (e.g. 5s)", key, value))
+				return
+			}
+			*target = d
+		}
+	}
+
+	setString("BOT_API_TOKEN", &cfg.Token)
+	setString("DATA_DIR", &cfg.DataDir)
+	setString("TODO_API_URL", &cfg.BackendURL)
+	setString("COMMAND_PREFIX", &cfg.CommandPrefix)
+	setInt("PAGE_SIZE", &cfg.PageSize)
+	setString("LOG_LEVEL", &cfg.LogLevel)
+	setString("TIMEZONE", &cfg.Timezone)
+	setString("LLM_PROVIDER", &cfg.LLM.Provider)
+	setString("LLM_MODEL", &cfg.LLM.Model)
+	// GEMINI_CREDS is the original name of the key, LLM_API_KEY wins if both are set
+	setString("GEMINI_CREDS", &cfg.LLM.APIKey)
+	setString("LLM_API_KEY", &cfg.LLM.APIKey)
+	setString("LLM_BASE_URL", &cfg.LLM.BaseURL)
+	setFloat("LLM_TEMPERATURE", &cfg.LLM.Temperature)
+	setInt("LLM_MAX_TOKENS", &cfg.LLM.MaxTokens)
+	setDuration("BACKEND_TIMEOUT", &cfg.Timeouts.Backend)
+	setDuration("COMMAND_TIMEOUT", &cfg.Timeouts.Command)
+	setBool("REMINDERS_ENABLED", &cfg.Reminders.Enabled)
+	setDurations("REMINDER_OFFSETS", &cfg.Reminders.Offsets)
+	setBool("REMINDER_OVERDUE", &cfg.Reminders.Overdue)
+
+	return problems
+}
+
+// flagValues holds the raw command line flag values
+type flagValues struct {
+	configFile      string
+	token           string
+	dataDir         string
+	backendURL      string
+	commandPrefix   string
+	pageSize        int
+	logLevel        string
+	timezone        string
+	llmProvider     string
+	llmModel        string
+	llmAPIKey       string
+	llmBaseURL      string
+	backendTimeout  time.Duration
+	commandTimeout  time.Duration
+	reminders       bool
+	reminderOffsets []time.Duration
+}
+
+func newFlagSet() (*flag.FlagSet, *flagValues) {
+	values := &flagValues{}
+	flags := flag.NewFlagSet("winayabot", flag.ContinueOnError)
+
+	flags.StringVar(&values.configFile, "config", "", "path to the YAML config file (default "+defaultConfigFile+")")
+	flags.StringVar(&values.token, "token", "", "Discord bot token")
+	flags.StringVar(&values.dataDir, "data-dir", "", "directory for the state database")
+	flags.StringVar(&values.backendURL, "backend-url", "", "base URL of the todo API")
+	flags.StringVar(&values.commandPrefix, "prefix", "", "prefix for text commands")
+	flags.IntVar(&values.pageSize, "page-size", 0, "tasks per page of the todo list")
+	flags.StringVar(&values.logLevel, "log-level", "", "log level: "+strings.Join(LogLevels, ", "))
+	flags.StringVar(&values.timezone, "timezone", "", "default timezone for due dates, e.g. "+defaultTimezone)
+	flags.StringVar(&values.llmProvider, "llm-provider", "", "LLM provider: "+strings.Join(LLMProviders, ", "))
+	flags.StringVar(&values.llmModel, "llm-model", "", "LLM model name (default depends on the provider)")
+	flags.StringVar(&values.llmAPIKey, "llm-api-key", "", "LLM API key")
+	flags.StringVar(&values.llmBaseURL, "llm-base-url", "", "LLM endpoint, for ollama or self-hosted OpenAI compatible APIs")
+	flags.DurationVar(&values.backendTimeout, "backend-timeout", 0, "timeout of a single todo API request")
+	flags.DurationVar(&values.commandTimeout, "command-timeout", 0, "timeout for all todo API calls of one command")
+	flags.BoolVar(&values.reminders, "reminders", true, "send due date reminders in DM")
+	flags.Func("reminder-offsets", "comma separated times before the due date to remind at (default 24h,1h)", func(value string) error {
+		list, err := parseDurationList(value)
+		values.reminderOffsets = list
+		return err
+	})
+
+	return flags, values
+}
+
+// applyFlags overrides cfg with every flag given on the command line
+func applyFlags(cfg *AppConfig, flags *flag.FlagSet, values *flagValues) {
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "token":
+			cfg.Token = values.token
+		case "data-dir":
+			cfg.DataDir = values.dataDir
+		case "backend-url":
+			cfg.BackendURL = values.backendURL
+		case "prefix":
+			cfg.CommandPrefix = values.commandPrefix
+		case "page-size":
+			cfg.PageSize = values.pageSize
+		case "log-level":
+			cfg.LogLevel = values.logLevel
+		case "timezone":
+			cfg.Timezone = values.timezone
+		case "llm-provider":
+			cfg.LLM.Provider = values.llmProvider
+		case "llm-model":
+			cfg.LLM.Model = values.llmModel
+		case "llm-api-key":
+			cfg.LLM.APIKey = values.llmAPIKey
+		case "llm-base-url":
+			cfg.LLM.BaseURL = values.llmBaseURL
+		case "backend-timeout":
+			cfg.Timeouts.Backend = values.backendTimeout
+		case "command-timeout":
+			cfg.Timeouts.Command = values.commandTimeout
+		case "reminders":
+			cfg.Reminders.Enabled = values.reminders
+		case "reminder-offsets":
+			cfg.Reminders.Offsets = values.reminderOffsets
+		}
+	})
+}
+
+// parseDurationList parses a comma separated list of durations such as "24h,1h"
+func parseDurationList(value string) ([]time.Duration, error) {
+	var list []time.Duration
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		d, err := time.ParseDuration(part)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a duration (e.g. 1h)", part)
+		}
+		list = append(list, d)
+	}
+	return list, nil
+}
+
+// Validate checks every setting and returns one error per problem found
+func (c *AppConfig) Validate() []error {
+	var problems []error
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	if c.Token == "" {
+		add("token is missing (BOT_API_TOKEN, -token or token in the config file)")
+	}
+
+	if c.BackendURL == "" {
+		add("backend_url is missing (TODO_API_URL, -backend-url or backend_url in the config file)")
+	} else if u, err := url.Parse(c.BackendURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		add("backend_url %q must be an http(s) URL with a host", c.BackendURL)
+	}
+
+	if c.CommandPrefix == "" || strings.ContainsAny(c.CommandPrefix, " \t\n") {
+		add("command_prefix %q must be non-empty and contain no whitespace", c.CommandPrefix)
+	}
+
+	if c.PageSize < 1 || c.PageSize > maxPageSize {
+		add("page_size %d must be between 1 and %d", c.PageSize, maxPageSize)
+	}
+
+	if !contains(LogLevels, c.LogLevel) {
+		add("log_level %q must be one of %s", c.LogLevel, strings.Join(LogLevels, ", "))
+	}
+
+	if _, err := time.LoadLocation(c.Timezone); err != nil || c.Timezone == "" {
+		add("timezone %q must be an IANA time zone such as %s", c.Timezone, defaultTimezone)
+	}
+
+	if !contains(LLMProviders, c.LLM.Provider) {
+		add("llm.provider %q must be one of %s", c.LLM.Provider, strings.Join(LLMProviders, ", "))
+	}
+	// Ollama and self-hosted OpenAI compatible servers run without a key
+	needsKey := c.LLM.Provider == "gemini" || (c.LLM.Provider == "openai" && c.LLM.BaseURL == "")
+	if needsKey && c.LLM.APIKey == "" {
+		add("llm.api_key is missing (LLM_API_KEY, -llm-api-key or llm.api_key in the config file)")
+	}
+	if c.LLM.BaseURL != "" {
+		if u, err := url.Parse(c.LLM.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("llm.base_url %q must be an http(s) URL with a host", c.LLM.BaseURL)
+		}
+	}
+	if t := c.LLM.Temperature; t != nil && (*t < 0 || *t > 2) {
+		add("llm.temperature %g must be between 0 and 2", *t)
+	}
+	if c.LLM.MaxTokens < 0 {
+		add("llm.max_tokens must not be negative, got %d", c.LLM.MaxTokens)
+	}
+
+	if c.Timeouts.Backend <= 0 {
+		add("timeouts.backend must be positive, got %s", c.Timeouts.Backend)
+	}
+	if c.Timeouts.Command <= 0 {
+		add("timeouts.command must be positive, got %s", c.Timeouts.Command)
+	}
+
+	if c.Reminders.Enabled {
+		for _, offset := range c.Reminders.Offsets {
+			if offset <= 0 {
+				add("reminders.offsets must all be positive, got %s", offset)
+			}
+		}
+		if c.Reminders.CheckInterval <= 0 {
+			add("reminders.check_interval must be positive, got %s", c.Reminders.CheckInterval)
+		}
+		if c.Reminders.PollInterval <= 0 {
+			add("reminders.poll_interval must be positive, got %s", c.Reminders.PollInterval)
+		}
+	}
+
+	return problems
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
 	}
+	return false
 }
@@ -0,0 +1,236 @@
+package date_utils
+
+/*
+Natural language due date parsing, in English and Indonesian.
+Examples of what is understood:
+- 2026-10-20, 2026-10-20 14:30, 20/10/2026, 20-10-2026 9am
+- today, tonight, tomorrow, hari ini, besok, lusa
+- friday, next friday, jumat, jumat depan (the next one after today)
+- next week, minggu depan, next month, bulan depan
+- in 3 days, in 2 weeks, in 4 hours, 3 hari lagi, 2 minggu lagi, 4 jam lagi
+Any of the day forms can be followed (or preceded) by a time such as
+9am, 9:30pm, 14:00, 14.00, jam 9, pukul 14.30, at 9am.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnrecognized is returned when the input is not a date we understand
+var ErrUnrecognized = errors.New("unrecognized date")
+
+// Date-only inputs are due at the end of that day
+const (
+	endOfDayHour   = 23
+	endOfDayMinute = 59
+)
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday, "minggu": time.Sunday, "ahad": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday, "senin": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "selasa": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday, "rabu": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "kamis": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday, "jumat": time.Friday, "jum'at": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday, "sabtu": time.Saturday,
+}
+
+var (
+	// 9am, 9:30pm, 9.30 pm
+	ampmPattern = regexp.MustCompile(`\b(\d{1,2})(?:[:.](\d{2}))?\s*(am|pm)\b`)
+	// 14:00, 14.00, jam 9, pukul 14.30, at 14
+	clockPattern = regexp.MustCompile(`(?:\b(?:jam|pukul|at)\s+(\d{1,2})(?:[:.](\d{2}))?\b)|(?:\b(\d{1,2})[:.](\d{2})\b)`)
+	// 2026-10-20
+	isoPattern = regexp.MustCompile(`^(\d{4})-(\d{1,2})-(\d{1,2})$`)
+	// 20/10/2026 or 20-10-2026, day first as used in Indonesia
+	dmyPattern = regexp.MustCompile(`^(\d{1,2})[/-](\d{1,2})[/-](\d{4})$`)
+	// in 3 days, 3 hari lagi
+	relativePattern = regexp.MustCompile(`^(?:in\s+)?(\d+)\s*(minutes?|mins?|menit|hours?|hrs?|jam|days?|hari|weeks?|minggu|months?|bulan)(?:\s+(?:lagi|from now))?$`)
+)
+
+// ParseDue parses a due date relative to now, interpreting it in loc.
+// The result is in loc.
+func ParseDue(input string, now time.Time, loc *time.Location) (time.Time, error) {
+	now = now.In(loc)
+	text := strings.ToLower(strings.TrimSpace(input))
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return time.Time{}, ErrUnrecognized
+	}
+
+	// ISO 8601 with a time is handled by the standard library directly
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, text, loc); err == nil {
+			return t.In(loc), nil
+		}
+	}
+
+	// Relative durations carry their own time of day
+	if m := relativePattern.FindStringSubmatch(text); m != nil {
+		return addRelative(now, m[1], m[2])
+	}
+
+	// Split off the time of day, if any
+	hour, minute, hasTime, rest, err := extractTime(text)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	day, err := parseDay(rest, now, loc, hasTime)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if !hasTime {
+		hour, minute = endOfDayHour, endOfDayMinute
+	}
+	due := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc)
+
+	// A bare time that already passed today means tomorrow
+	if rest == "" && due.Before(now) {
+		due = due.AddDate(0, 0, 1)
+	}
+	return due, nil
+}
+
+// extractTime removes a time of day from text and returns it together with the rest
+func extractTime(text string) (hour int, minute int, found bool, rest string, err error) {
+	if m := ampmPattern.FindStringSubmatchIndex(text); m != nil {
+		hour, _ = strconv.Atoi(text[m[2]:m[3]])
+		if m[4] >= 0 {
+			minute, _ = strconv.Atoi(text[m[4]:m[5]])
+		}
+		suffix := text[m[6]:m[7]]
+		if hour < 1 || hour > 12 || minute > 59 {
+			return 0, 0, false, "", fmt.Errorf("%q is not a valid time", text[m[0]:m[1]])
+		}
+		if suffix == "pm" && hour != 12 {
+			hour += 12
+		}
+		if suffix == "am" && hour == 12 {
+			hour = 0
+		}
+		return hour, minute, true, cleanRest(text[:m[0]] + " " + text[m[1]:]), nil
+	}
+
+	if m := clockPattern.FindStringSubmatchIndex(text); m != nil {
+		// The pattern has two alternatives, pick whichever matched
+		hourIdx, minuteIdx := 2, 4
+		if m[2] < 0 {
+			hourIdx, minuteIdx = 6, 8
+		}
+		hour, _ = strconv.Atoi(text[m[hourIdx]:m[hourIdx+1]])
+		if m[minuteIdx] >= 0 {
+			minute, _ = strconv.Atoi(text[m[minuteIdx]:m[minuteIdx+1]])
+		}
+		if hour > 23 || minute > 59 {
+			return 0, 0, false, "", fmt.Errorf("%q is not a valid time", text[m[0]:m[1]])
+		}
+		return hour, minute, true, cleanRest(text[:m[0]] + " " + text[m[1]:]), nil
+	}
+
+	return 0, 0, false, text, nil
+}
+
+// cleanRest tidies up what is left after removing the time
+func cleanRest(text string) string {
+	fields := strings.Fields(text)
+	// Drop dangling connectors like "tomorrow at" or "besok pukul"
+	for len(fields) > 0 {
+		last := fields[len(fields)-1]
+		if last != "at" && last != "jam" && last != "pukul" && last != "on" {
+			break
+		}
+		fields = fields[:len(fields)-1]
+	}
+	return strings.Join(fields, " ")
+}
+
+// parseDay turns the date part of the input into a day. An empty date part means
+// today, which is only allowed together with a time.
+func parseDay(text string, now time.Time, loc *time.Location, hasTime bool) (time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	switch text {
+	case "":
+		if !hasTime {
+			return time.Time{}, ErrUnrecognized
+		}
+		return today, nil
+	case "today", "tonight", "hari ini", "nanti", "malam ini":
+		return today, nil
+	case "tomorrow", "tmr", "tmrw", "besok":
+		return today.AddDate(0, 0, 1), nil
+	case "day after tomorrow", "lusa":
+		return today.AddDate(0, 0, 2), nil
+	case "next week", "minggu depan":
+		return today.AddDate(0, 0, 7), nil
+	case "next month", "bulan depan":
+		return today.AddDate(0, 1, 0), nil
+	}
+
+	if m := isoPattern.FindStringSubmatch(text); m != nil {
+		return makeDate(m[1], m[2], m[3], loc)
+	}
+	if m := dmyPattern.FindStringSubmatch(text); m != nil {
+		return makeDate(m[3], m[2], m[1], loc)
+	}
+
+	// friday, next friday, this friday, on friday, jumat, jumat depan, hari jumat
+	name := text
+	for _, prefix := range []string{"next ", "this ", "on ", "hari "} {
+		name = strings.TrimPrefix(name, prefix)
+	}
+	for _, suffix := range []string{" depan", " ini"} {
+		name = strings.TrimSuffix(name, suffix)
+	}
+	if weekday, ok := weekdays[name]; ok {
+		days := (int(weekday) - int(today.Weekday()) + 7) % 7
+		if days == 0 {
+			days = 7
+		}
+		return today.AddDate(0, 0, days), nil
+	}
+
+	return time.Time{}, ErrUnrecognized
+}
+
+// makeDate builds a day from its parts, rejecting dates like 2026-02-31
+func makeDate(year, month, day string, loc *time.Location) (time.Time, error) {
+	y, _ := strconv.Atoi(year)
+	m, _ := strconv.Atoi(month)
+	d, _ := strconv.Atoi(day)
+
+	t := time.Date(y, time.Month(m), d, 0, 0, 0, 0, loc)
+	if t.Year() != y || int(t.Month()) != m || t.Day() != d {
+		return time.Time{}, fmt.Errorf("%04d-%02d-%02d is not a valid date", y, m, d)
+	}
+	return t, nil
+}
+
+// addRelative handles "in 3 days" style inputs
+func addRelative(now time.Time, amount string, unit string) (time.Time, error) {
+	n, err := strconv.Atoi(amount)
+	if err != nil {
+		return time.Time{}, ErrUnrecognized
+	}
+
+	switch {
+	case strings.HasPrefix(unit, "min") || unit == "menit":
+		return now.Add(time.Duration(n) * time.Minute), nil
+	case strings.HasPrefix(unit, "day") || unit == "hari":
+		return now.AddDate(0, 0, n), nil
+	case strings.HasPrefix(unit, "h") || unit == "jam":
+		return now.Add(time.Duration(n) * time.Hour), nil
+	case strings.HasPrefix(unit, "week") || unit == "minggu":
+		return now.AddDate(0, 0, 7*n), nil
+	case strings.HasPrefix(unit, "month") || unit == "bulan":
+		return now.AddDate(0, n, 0), nil
+	}
+	return time.Time{}, ErrUnrecognized
+}
@@ -0,0 +1,232 @@
+package llm_utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// defaultGeminiURL is the Gemini API endpoint
+	defaultGeminiURL = "https://generativelanguage.googleapis.com/v1beta"
+	// defaultGeminiModel is used when no model is configured
+	defaultGeminiModel = "gemini-2.0-flash"
+)
+
+// GeminiProvider is an LLMProvider for Google's Gemini API.
+type GeminiProvider struct {
+	APIKey string
+	// BaseURL defaults to defaultGeminiURL
+	BaseURL  string
+	Defaults GenerateOptions
+	// Client defaults to http.DefaultClient
+	Client *http.Client
+}
+
+// --- Gemini API Request/Response Structs ---
+
+// GeminiRequestPayload is the structure for the request body sent to Gemini.
+type GeminiRequestPayload struct {
+	Contents          []Content         `json:"contents"`
+	SystemInstruction *Content          `json:"systemInstruction,omitempty"`
+	Tools             []Tool            `json:"tools,omitempty"`
+	GenerationConfig  *GenerationConfig `json:"generationConfig,omitempty"`
+}
+
+// GenerationConfig tunes how Gemini replies.
+type GenerationConfig struct {
+	ResponseMimeType string   `json:"responseMimeType,omitempty"`
+	Temperature      *float64 `json:"temperature,omitempty"`
+	MaxOutputTokens  int      `json:"maxOutputTokens,omitempty"`
+}
+
+type Content struct {
+	// Role is "user" or "model". It can be left empty for single prompts.
+	Role  string `json:"role,omitempty"`
+	Parts []Part `json:"parts"`
+}
+
+// Part is one piece of a Content: text, a function call by the model or the result of one.
+type Part struct {
+	Text             string            `json:"text,omitempty"`
+	FunctionCall     *FunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *FunctionResponse `json:"functionResponse,omitempty"`
+}
+
+// Tool offers the model functions it may call instead of answering right away.
+type Tool struct {
+	FunctionDeclarations []FunctionDeclaration `json:"functionDeclarations"`
+}
+
+// FunctionDeclaration describes one function the model may call.
+type FunctionDeclaration struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Parameters  *Schema `json:"parameters,omitempty"`
+}
+
+// FunctionCall is the model asking for a function to be run.
+type FunctionCall struct {
+	Name string                 `json:"name"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+// FunctionResponse hands the result of a FunctionCall back to the model.
+type FunctionResponse struct {
+	Name     string                 `json:"name"`
+	Response map[string]interface{} `json:"response"`
+}
+
+// GeminiResponsePayload is the structure for parsing the response from Gemini.
+type GeminiResponsePayload struct {
+	Candidates []Candidate `json:"candidates"`
+}
+
+type Candidate struct {
+	Content Content `json:"content"`
+}
+
+// --- LLMProvider implementation ---
+
+// Generate implements LLMProvider
+func (g *GeminiProvider) Generate(ctx context.Context, prompt string, opts ...Option) (string, error) {
+	options := resolveOptions(g.Defaults, defaultGeminiModel, opts)
+	responseData, err := g.post(ctx, options, "generateContent", g.payload(options, []Content{{Parts: []Part{{Text: prompt}}}}))
+	if err != nil {
+		return "", err
+	}
+
+	// Extract the text from the response structure.
+	if len(responseData.Candidates) > 0 && len(responseData.Candidates[0].Content.Parts) > 0 {
+		return responseData.Candidates[0].Content.Parts[0].Text, nil
+	}
+	return "", fmt.Errorf("no text found in Gemini response")
+}
+
+// GenerateStream implements LLMProvider
+func (g *GeminiProvider) GenerateStream(ctx context.Context, prompt string, onChunk func(chunk string), opts ...Option) (string, error) {
+	options := resolveOptions(g.Defaults, defaultGeminiModel, opts)
+	resp, err := postJSON(ctx, g.Client, g.url(options, "streamGenerateContent")+"?alt=sse", g.headers(), g.payload(options, []Content{{Parts: []Part{{Text: prompt}}}}))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var reply strings.Builder
+	err = readLines(resp.Body, true, func(data string) error {
+		var chunk GeminiResponsePayload
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("error decoding Gemini stream: %w", err)
+		}
+		for _, candidate := range chunk.Candidates {
+			for _, part := range candidate.Content.Parts {
+				if part.Text != "" {
+					reply.WriteString(part.Text)
+					onChunk(part.Text)
+				}
+			}
+		}
+		return nil
+	})
+	return reply.String(), err
+}
+
+// Chat implements LLMProvider
+func (g *GeminiProvider) Chat(ctx context.Context, system string, history []Message, tools []ToolDef, opts ...Option) (Message, error) {
+	options := resolveOptions(g.Defaults, defaultGeminiModel, opts)
+
+	contents := make([]Content, 0, len(history))
+	for _, message := range history {
+		contents = append(contents, geminiContent(message))
+	}
+	payload := g.payload(options, contents)
+	if system != "" {
+		payload.SystemInstruction = &Content{Parts: []Part{{Text: system}}}
+	}
+	if len(tools) > 0 {
+		declarations := make([]FunctionDeclaration, len(tools))
+		for i, tool := range tools {
+			declarations[i] = FunctionDeclaration{Name: tool.Name, Description: tool.Description, Parameters: tool.Parameters}
+		}
+		payload.Tools = []Tool{{FunctionDeclarations: declarations}}
+	}
+
+	responseData, err := g.post(ctx, options, "generateContent", payload)
+	if err != nil {
+		return Message{}, err
+	}
+	if len(responseData.Candidates) == 0 || len(responseData.Candidates[0].Content.Parts) == 0 {
+		return Message{}, fmt.Errorf("no content found in Gemini response")
+	}
+
+	reply := Message{Role: RoleAssistant}
+	for _, part := range responseData.Candidates[0].Content.Parts {
+		reply.Text += part.Text
+		if part.FunctionCall != nil {
+			// Gemini has no call IDs, results are matched by name
+			reply.ToolCalls = append(reply.ToolCalls, ToolCall{Name: part.FunctionCall.Name, Args: part.FunctionCall.Args})
+		}
+	}
+	return reply, nil
+}
+
+// geminiContent converts a Message to Gemini's format
+func geminiContent(message Message) Content {
+	content := Content{Role: "user"}
+	if message.Role == RoleAssistant {
+		content.Role = "model"
+	}
+	if message.Text != "" {
+		content.Parts = append(content.Parts, Part{Text: message.Text})
+	}
+	for _, call := range message.ToolCalls {
+		content.Parts = append(content.Parts, Part{FunctionCall: &FunctionCall{Name: call.Name, Args: call.Args}})
+	}
+	for _, result := range message.ToolResults {
+		content.Parts = append(content.Parts, Part{FunctionResponse: &FunctionResponse{Name: result.Name, Response: result.Response}})
+	}
+	return content
+}
+
+// payload builds a request for the contents with the generation options applied
+func (g *GeminiProvider) payload(options GenerateOptions, contents []Content) GeminiRequestPayload {
+	payload := GeminiRequestPayload{Contents: contents}
+	if options.JSON || options.Temperature != nil || options.MaxTokens > 0 {
+		payload.GenerationConfig = &GenerationConfig{Temperature: options.Temperature, MaxOutputTokens: options.MaxTokens}
+		if options.JSON {
+			payload.GenerationConfig.ResponseMimeType = "application/json"
+		}
+	}
+	return payload
+}
+
+// url is the endpoint of a method of the model, e.g. generateContent
+func (g *GeminiProvider) url(options GenerateOptions, method string) string {
+	baseURL := g.BaseURL
+	if baseURL == "" {
+		baseURL = defaultGeminiURL
+	}
+	return strings.TrimSuffix(baseURL, "/") + "/models/" + options.Model + ":" + method
+}
+
+// headers authenticate a request. The key goes in a header so it never shows up in logged URLs.
+func (g *GeminiProvider) headers() map[string]string {
+	return map[string]string{"x-goog-api-key": g.APIKey}
+}
+
+// post sends the payload to a method of the model and decodes the response.
+func (g *GeminiProvider) post(ctx context.Context, options GenerateOptions, method string, payload GeminiRequestPayload) (*GeminiResponsePayload, error) {
+	resp, err := postJSON(ctx, g.Client, g.url(options, method), g.headers(), payload)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var responseData GeminiResponsePayload
+	if err := json.NewDecoder(resp.Body).Decode(&responseData); err != nil {
+		return nil, fmt.Errorf("error decoding Gemini API response: %w", err)
+	}
+	return &responseData, nil
+}
@@ -4,107 +4,65 @@ package llm_utils
 All LLM related functions will be implemented here
 - summarize and its utils
 - (CS) summarize from a webpage
--
+- focus for today, for the daily digest
 
 */
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 )
 
-// LLMService holds the necessary information to interact with the Gemini API.
+// LLMService runs the bot's prompts on whichever LLMProvider is configured.
 type LLMService struct {
-	APIKey string
-}
-
-// --- Gemini API Request/Response Structs ---
-
-// GeminiRequestPayload is the structure for the request body sent to Gemini.
-type GeminiRequestPayload struct {
-	Contents []Content `json:"contents"`
-}
-
-type Content struct {
-	Parts []Part `json:"parts"`
-}
-
-type Part struct {
-	Text string `json:"text"`
-}
-
-// GeminiResponsePayload is the structure for parsing the response from Gemini.
-type GeminiResponsePayload struct {
-	Candidates []Candidate `json:"candidates"`
-}
-
-type Candidate struct {
-	Content Content `json:"content"`
+	Provider LLMProvider
 }
 
 // --- Service Implementation ---
 
-// NewLLMService creates a new instance of the LLMService for Gemini.
-func NewLLMService(apiKey string) *LLMService {
-	return &LLMService{APIKey: apiKey}
+// NewLLMService creates a new instance of the LLMService on top of a provider.
+func NewLLMService(provider LLMProvider) *LLMService {
+	return &LLMService{Provider: provider}
 }
 
-// SummarizeFromText takes text, sends it to the Gemini API for summarization, and returns the result.
-func (l *LLMService) SummarizeFromText(text string) (string, error) {
-	// 1. Construct the Gemini API endpoint URL.
-	// We use gemini-pro as a good general-purpose model.
-	apiURL := "https://generativelanguage.googleapis.com/v1beta/models/gemini-2.0-flash:generateContent?key=" + l.APIKey
-
-	// 2. Construct the prompt.
-	prompt := fmt.Sprintf("Anda adalah seorang summarizer handal. Buatlah ringkasan singkat dan substansial dari teks berikut. respon"+
+// summarizePrompt asks for a summary of text in its own language
+func summarizePrompt(text string) string {
+	return fmt.Sprintf("Anda adalah seorang summarizer handal. Buatlah ringkasan singkat dan substansial dari teks berikut. respon"+
 		"dengan bahasa yang sama dengan bahasa dari text tersebut: \"%s\"", text)
+}
 
-	// 3. Create the request payload using the structs we defined.
-	payload := GeminiRequestPayload{
-		Contents: []Content{
-			{
-				Parts: []Part{
-					{Text: prompt},
-				},
-			},
-		},
-	}
-
-	requestBody, err := json.Marshal(payload)
-	if err != nil {
-		return "", fmt.Errorf("error marshalling request body: %w", err)
-	}
-
-	// 4. Create and send the HTTP request.
-	resp, err := http.Post(apiURL, "application/json", bytes.NewBuffer(requestBody))
+// SummarizeFromText takes text, sends it to the LLM for summarization, and returns the result.
+func (l *LLMService) SummarizeFromText(ctx context.Context, text string) (string, error) {
+	summary, err := l.Provider.Generate(ctx, summarizePrompt(text))
 	if err != nil {
-		return "", fmt.Errorf("error sending request to Gemini API: %w", err)
+		return "", err
 	}
-	defer resp.Body.Close()
+	log.Println("Successfully received summary from the LLM.")
+	return summary, nil
+}
 
-	// 5. Read and handle the response.
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("Gemini API returned non-200 status: %s - %s", resp.Status, string(bodyBytes))
-	}
+// SummarizeFromTextStream is like SummarizeFromText, but calls onChunk with every piece
+// of the summary as it is written.
+func (l *LLMService) SummarizeFromTextStream(ctx context.Context, text string, onChunk func(chunk string)) (string, error) {
+	return l.Provider.GenerateStream(ctx, summarizePrompt(text), onChunk)
+}
 
-	// 6. Parse the JSON response to extract the summary.
-	var responseData GeminiResponsePayload
-	if err := json.NewDecoder(resp.Body).Decode(&responseData); err != nil {
-		return "", fmt.Errorf("error decoding Gemini API response: %w", err)
-	}
+// FocusForToday writes a short paragraph suggesting what to focus on today,
+// given a plain text overview of the user's tasks.
+func (l *LLMService) FocusForToday(ctx context.Context, tasks string) (string, error) {
+	prompt := "You are a friendly productivity coach. Based on the task overview below, write ONE short paragraph " +
+		"(at most 3 sentences) suggesting what the user should focus on today and why. Prioritise overdue tasks and " +
+		"tasks due today, then work already in progress. Do not list every task. Reply in the same language as the " +
+		"task titles.\n\n" + tasks
 
-	// 7. Extract the text from the response structure.
-	if len(responseData.Candidates) > 0 && len(responseData.Candidates[0].Content.Parts) > 0 {
-		summary := responseData.Candidates[0].Content.Parts[0].Text
-		log.Println("Successfully received summary from Gemini.")
-		return summary, nil
-	}
+	return l.Provider.Generate(ctx, prompt, WithMaxTokens(300))
+}
 
-	return "", fmt.Errorf("no summary found in Gemini response")
+// Chat continues a conversation with the assistant, offering it the given tools.
+func (l *LLMService) Chat(ctx context.Context, system string, history []Message, tools []ToolDef) (Message, error) {
+	return l.Provider.Chat(ctx, system, history, tools)
 }
 
 func (l *LLMService) ReadWebPages(url string) (string, error) {
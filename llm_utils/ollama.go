@@ -0,0 +1,186 @@
+package llm_utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// defaultOllamaURL is where a local Ollama listens
+	defaultOllamaURL = "http://localhost:11434"
+	// defaultOllamaModel is used when no model is configured
+	defaultOllamaModel = "llama3.1"
+)
+
+// OllamaProvider is an LLMProvider for a local Ollama server. It needs no API key.
+type OllamaProvider struct {
+	// BaseURL defaults to defaultOllamaURL
+	BaseURL  string
+	Defaults GenerateOptions
+	// Client defaults to http.DefaultClient
+	Client *http.Client
+}
+
+// --- Ollama API Request/Response Structs ---
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Tools    []openAITool    `json:"tools,omitempty"`
+	// Format is "json" to ask for a JSON reply
+	Format  string        `json:"format,omitempty"`
+	Options ollamaOptions `json:"options,omitempty"`
+	// Stream must be sent as false, Ollama streams by default
+	Stream bool `json:"stream"`
+}
+
+type ollamaOptions struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	NumPredict  int      `json:"num_predict,omitempty"`
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	// ToolName names the tool a "tool" message is the result of
+	ToolName string `json:"tool_name,omitempty"`
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string                 `json:"name"`
+		Arguments map[string]interface{} `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaResponse struct {
+	Message ollamaMessage `json:"message"`
+	Done    bool          `json:"done"`
+}
+
+// --- LLMProvider implementation ---
+
+// Generate implements LLMProvider
+func (o *OllamaProvider) Generate(ctx context.Context, prompt string, opts ...Option) (string, error) {
+	reply, err := o.chat(ctx, o.request(opts, []ollamaMessage{{Role: "user", Content: prompt}}))
+	if err != nil {
+		return "", err
+	}
+	return reply.Content, nil
+}
+
+// GenerateStream implements LLMProvider
+func (o *OllamaProvider) GenerateStream(ctx context.Context, prompt string, onChunk func(chunk string), opts ...Option) (string, error) {
+	request := o.request(opts, []ollamaMessage{{Role: "user", Content: prompt}})
+	request.Stream = true
+	resp, err := postJSON(ctx, o.Client, o.url(), nil, request)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// The stream is one JSON object per line
+	var reply strings.Builder
+	err = readLines(resp.Body, false, func(line string) error {
+		var chunk ollamaResponse
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return fmt.Errorf("error decoding Ollama stream: %w", err)
+		}
+		if chunk.Message.Content != "" {
+			reply.WriteString(chunk.Message.Content)
+			onChunk(chunk.Message.Content)
+		}
+		return nil
+	})
+	return reply.String(), err
+}
+
+// Chat implements LLMProvider. Tools need a model that supports them, e.g. llama3.1 or qwen2.5.
+func (o *OllamaProvider) Chat(ctx context.Context, system string, history []Message, tools []ToolDef, opts ...Option) (Message, error) {
+	var messages []ollamaMessage
+	if system != "" {
+		messages = append(messages, ollamaMessage{Role: "system", Content: system})
+	}
+	for _, message := range history {
+		messages = append(messages, ollamaMessages(message)...)
+	}
+
+	request := o.request(opts, messages)
+	for _, tool := range tools {
+		// Ollama takes tools in OpenAI's format
+		request.Tools = append(request.Tools, openAITool{Type: "function", Function: openAIFunction{Name: tool.Name, Description: tool.Description, Parameters: tool.Parameters}})
+	}
+
+	message, err := o.chat(ctx, request)
+	if err != nil {
+		return Message{}, err
+	}
+
+	reply := Message{Role: RoleAssistant, Text: message.Content}
+	for _, call := range message.ToolCalls {
+		reply.ToolCalls = append(reply.ToolCalls, ToolCall{Name: call.Function.Name, Args: call.Function.Arguments})
+	}
+	return reply, nil
+}
+
+// ollamaMessages converts a Message to Ollama's format. Every tool result is a message of its own.
+func ollamaMessages(message Message) []ollamaMessage {
+	if len(message.ToolResults) > 0 {
+		messages := make([]ollamaMessage, len(message.ToolResults))
+		for i, result := range message.ToolResults {
+			messages[i] = ollamaMessage{Role: "tool", ToolName: result.Name, Content: marshalResponse(result.Response)}
+		}
+		return messages
+	}
+
+	converted := ollamaMessage{Role: message.Role, Content: message.Text}
+	for _, call := range message.ToolCalls {
+		var toolCall ollamaToolCall
+		toolCall.Function.Name = call.Name
+		toolCall.Function.Arguments = call.Args
+		converted.ToolCalls = append(converted.ToolCalls, toolCall)
+	}
+	return []ollamaMessage{converted}
+}
+
+// request builds a chat request with the generation options applied
+func (o *OllamaProvider) request(opts []Option, messages []ollamaMessage) ollamaRequest {
+	options := resolveOptions(o.Defaults, defaultOllamaModel, opts)
+	request := ollamaRequest{
+		Model:    options.Model,
+		Messages: messages,
+		Options:  ollamaOptions{Temperature: options.Temperature, NumPredict: options.MaxTokens},
+	}
+	if options.JSON {
+		request.Format = "json"
+	}
+	return request
+}
+
+// chat sends a chat request without streaming and returns the reply
+func (o *OllamaProvider) chat(ctx context.Context, request ollamaRequest) (ollamaMessage, error) {
+	resp, err := postJSON(ctx, o.Client, o.url(), nil, request)
+	if err != nil {
+		return ollamaMessage{}, err
+	}
+	defer resp.Body.Close()
+
+	var responseData ollamaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&responseData); err != nil {
+		return ollamaMessage{}, fmt.Errorf("error decoding Ollama response: %w", err)
+	}
+	return responseData.Message, nil
+}
+
+// url is the chat endpoint
+func (o *OllamaProvider) url() string {
+	baseURL := o.BaseURL
+	if baseURL == "" {
+		baseURL = defaultOllamaURL
+	}
+	return strings.TrimSuffix(baseURL, "/") + "/api/chat"
+}
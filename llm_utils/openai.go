@@ -0,0 +1,226 @@
+package llm_utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// defaultOpenAIURL is OpenAI's own API; any compatible API works through BaseURL
+	defaultOpenAIURL = "https://api.openai.com/v1"
+	// defaultOpenAIModel is used when no model is configured
+	defaultOpenAIModel = "gpt-4o-mini"
+)
+
+// OpenAIProvider is an LLMProvider for OpenAI's chat completions API and the many
+// servers compatible with it, e.g. OpenRouter, Groq, vLLM or LM Studio.
+type OpenAIProvider struct {
+	APIKey string
+	// BaseURL defaults to defaultOpenAIURL
+	BaseURL  string
+	Defaults GenerateOptions
+	// Client defaults to http.DefaultClient
+	Client *http.Client
+}
+
+// --- OpenAI API Request/Response Structs ---
+
+type openAIRequest struct {
+	Model          string                `json:"model"`
+	Messages       []openAIMessage       `json:"messages"`
+	Tools          []openAITool          `json:"tools,omitempty"`
+	Temperature    *float64              `json:"temperature,omitempty"`
+	MaxTokens      int                   `json:"max_tokens,omitempty"`
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+	Stream         bool                  `json:"stream,omitempty"`
+}
+
+type openAIResponseFormat struct {
+	Type string `json:"type"`
+}
+
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAITool struct {
+	Type     string         `json:"type"`
+	Function openAIFunction `json:"function"`
+}
+
+type openAIFunction struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Parameters  *Schema `json:"parameters,omitempty"`
+}
+
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name string `json:"name"`
+		// Arguments is a JSON object encoded as a string
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message openAIMessage `json:"message"`
+		// Delta is set instead of Message when streaming
+		Delta openAIMessage `json:"delta"`
+	} `json:"choices"`
+}
+
+// --- LLMProvider implementation ---
+
+// Generate implements LLMProvider
+func (o *OpenAIProvider) Generate(ctx context.Context, prompt string, opts ...Option) (string, error) {
+	reply, err := o.complete(ctx, o.request(opts, []openAIMessage{{Role: "user", Content: prompt}}))
+	if err != nil {
+		return "", err
+	}
+	return reply.Content, nil
+}
+
+// GenerateStream implements LLMProvider
+func (o *OpenAIProvider) GenerateStream(ctx context.Context, prompt string, onChunk func(chunk string), opts ...Option) (string, error) {
+	request := o.request(opts, []openAIMessage{{Role: "user", Content: prompt}})
+	request.Stream = true
+	resp, err := postJSON(ctx, o.Client, o.url(), o.headers(), request)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var reply strings.Builder
+	err = readLines(resp.Body, true, func(data string) error {
+		if data == "[DONE]" {
+			return nil
+		}
+		var chunk openAIResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("error decoding OpenAI stream: %w", err)
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				reply.WriteString(choice.Delta.Content)
+				onChunk(choice.Delta.Content)
+			}
+		}
+		return nil
+	})
+	return reply.String(), err
+}
+
+// Chat implements LLMProvider
+func (o *OpenAIProvider) Chat(ctx context.Context, system string, history []Message, tools []ToolDef, opts ...Option) (Message, error) {
+	var messages []openAIMessage
+	if system != "" {
+		messages = append(messages, openAIMessage{Role: "system", Content: system})
+	}
+	for _, message := range history {
+		messages = append(messages, openAIMessages(message)...)
+	}
+
+	request := o.request(opts, messages)
+	for _, tool := range tools {
+		request.Tools = append(request.Tools, openAITool{Type: "function", Function: openAIFunction{Name: tool.Name, Description: tool.Description, Parameters: tool.Parameters}})
+	}
+
+	choice, err := o.complete(ctx, request)
+	if err != nil {
+		return Message{}, err
+	}
+
+	reply := Message{Role: RoleAssistant, Text: choice.Content}
+	for _, call := range choice.ToolCalls {
+		var args map[string]interface{}
+		if call.Function.Arguments != "" {
+			if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+				return Message{}, fmt.Errorf("error decoding arguments of %s: %w", call.Function.Name, err)
+			}
+		}
+		reply.ToolCalls = append(reply.ToolCalls, ToolCall{ID: call.ID, Name: call.Function.Name, Args: args})
+	}
+	return reply, nil
+}
+
+// openAIMessages converts a Message to OpenAI's format. Every tool result is a message of its own.
+func openAIMessages(message Message) []openAIMessage {
+	if len(message.ToolResults) > 0 {
+		messages := make([]openAIMessage, len(message.ToolResults))
+		for i, result := range message.ToolResults {
+			messages[i] = openAIMessage{Role: "tool", ToolCallID: result.CallID, Content: marshalResponse(result.Response)}
+			if result.CallID == "" {
+				// Calls without an ID are numbered in order below, results follow the same order
+				messages[i].ToolCallID = "call_" + strconv.Itoa(i)
+			}
+		}
+		return messages
+	}
+
+	converted := openAIMessage{Role: message.Role, Content: message.Text}
+	for i, call := range message.ToolCalls {
+		toolCall := openAIToolCall{ID: call.ID, Type: "function"}
+		if toolCall.ID == "" {
+			toolCall.ID = "call_" + strconv.Itoa(i)
+		}
+		toolCall.Function.Name = call.Name
+		toolCall.Function.Arguments = marshalResponse(call.Args)
+		converted.ToolCalls = append(converted.ToolCalls, toolCall)
+	}
+	return []openAIMessage{converted}
+}
+
+// request builds a chat completion request with the generation options applied
+func (o *OpenAIProvider) request(opts []Option, messages []openAIMessage) openAIRequest {
+	options := resolveOptions(o.Defaults, defaultOpenAIModel, opts)
+	request := openAIRequest{Model: options.Model, Messages: messages, Temperature: options.Temperature, MaxTokens: options.MaxTokens}
+	if options.JSON {
+		request.ResponseFormat = &openAIResponseFormat{Type: "json_object"}
+	}
+	return request
+}
+
+// complete sends a chat completion request and returns the first choice
+func (o *OpenAIProvider) complete(ctx context.Context, request openAIRequest) (openAIMessage, error) {
+	resp, err := postJSON(ctx, o.Client, o.url(), o.headers(), request)
+	if err != nil {
+		return openAIMessage{}, err
+	}
+	defer resp.Body.Close()
+
+	var responseData openAIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&responseData); err != nil {
+		return openAIMessage{}, fmt.Errorf("error decoding OpenAI API response: %w", err)
+	}
+	if len(responseData.Choices) == 0 {
+		return openAIMessage{}, fmt.Errorf("no choices found in OpenAI response")
+	}
+	return responseData.Choices[0].Message, nil
+}
+
+// url is the chat completions endpoint
+func (o *OpenAIProvider) url() string {
+	baseURL := o.BaseURL
+	if baseURL == "" {
+		baseURL = defaultOpenAIURL
+	}
+	return strings.TrimSuffix(baseURL, "/") + "/chat/completions"
+}
+
+// headers authenticate a request. Local servers often need no key.
+func (o *OpenAIProvider) headers() map[string]string {
+	if o.APIKey == "" {
+		return nil
+	}
+	return map[string]string{"Authorization": "Bearer " + o.APIKey}
+}
@@ -0,0 +1,224 @@
+package llm_utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// LLMProvider is a backend that runs prompts, e.g. Gemini, an OpenAI compatible API or Ollama.
+// Everything else in the bot talks to LLMs through this interface.
+type LLMProvider interface {
+	// Generate sends a single prompt and returns the whole reply.
+	Generate(ctx context.Context, prompt string, opts ...Option) (string, error)
+	// GenerateStream sends a single prompt and calls onChunk with every piece of the reply
+	// as it arrives. It returns the whole reply once the stream has ended.
+	GenerateStream(ctx context.Context, prompt string, onChunk func(chunk string), opts ...Option) (string, error)
+	// Chat continues a conversation, offering the model the given tools, and returns its
+	// next message. The message holds text, tool calls, or both; tool results go back
+	// in a RoleUser message with ToolResults.
+	Chat(ctx context.Context, system string, history []Message, tools []ToolDef, opts ...Option) (Message, error)
+}
+
+// Provider names, as used in the llm.provider setting
+const (
+	ProviderGemini = "gemini"
+	ProviderOpenAI = "openai"
+	ProviderOllama = "ollama"
+)
+
+// ProviderConfig selects and configures a provider for NewProvider
+type ProviderConfig struct {
+	// Name is one of ProviderGemini, ProviderOpenAI or ProviderOllama
+	Name   string
+	APIKey string
+	// BaseURL overrides the provider's default endpoint, e.g. for a self-hosted OpenAI compatible API
+	BaseURL string
+	// Defaults apply to every call unless overridden by its options
+	Defaults GenerateOptions
+}
+
+// NewProvider creates the provider named in cfg
+func NewProvider(cfg ProviderConfig) (LLMProvider, error) {
+	client := &http.Client{}
+	switch cfg.Name {
+	case ProviderGemini:
+		return &GeminiProvider{APIKey: cfg.APIKey, BaseURL: cfg.BaseURL, Defaults: cfg.Defaults, Client: client}, nil
+	case ProviderOpenAI:
+		return &OpenAIProvider{APIKey: cfg.APIKey, BaseURL: cfg.BaseURL, Defaults: cfg.Defaults, Client: client}, nil
+	case ProviderOllama:
+		return &OllamaProvider{BaseURL: cfg.BaseURL, Defaults: cfg.Defaults, Client: client}, nil
+	}
+	return nil, fmt.Errorf("unknown LLM provider %q", cfg.Name)
+}
+
+// --- Options ---
+
+// GenerateOptions tune a single call. Zero values leave the provider's defaults.
+type GenerateOptions struct {
+	Model string
+	// Temperature is nil for the model's default, since 0 is a valid temperature
+	Temperature *float64
+	// MaxTokens caps the length of the reply, 0 means no cap
+	MaxTokens int
+	// JSON asks for a reply that is a single JSON object
+	JSON bool
+}
+
+// Option changes the GenerateOptions of a call
+type Option func(*GenerateOptions)
+
+// WithModel runs the call on another model than the configured one
+func WithModel(model string) Option {
+	return func(o *GenerateOptions) { o.Model = model }
+}
+
+// WithTemperature sets how random the reply is
+func WithTemperature(temperature float64) Option {
+	return func(o *GenerateOptions) { o.Temperature = &temperature }
+}
+
+// WithMaxTokens caps the length of the reply
+func WithMaxTokens(maxTokens int) Option {
+	return func(o *GenerateOptions) { o.MaxTokens = maxTokens }
+}
+
+// WithJSON asks for a reply that is a single JSON object
+func WithJSON() Option {
+	return func(o *GenerateOptions) { o.JSON = true }
+}
+
+// resolveOptions applies opts on top of the provider's defaults, falling back to defaultModel
+func resolveOptions(defaults GenerateOptions, defaultModel string, opts []Option) GenerateOptions {
+	resolved := defaults
+	for _, opt := range opts {
+		opt(&resolved)
+	}
+	if resolved.Model == "" {
+		resolved.Model = defaultModel
+	}
+	return resolved
+}
+
+// --- Conversations and tools ---
+
+// Roles of a Message
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message is one turn of a conversation passed to LLMProvider.Chat
+type Message struct {
+	// Role is RoleUser or RoleAssistant
+	Role string
+	Text string
+	// ToolCalls are the tools the assistant wants to run
+	ToolCalls []ToolCall
+	// ToolResults answer the ToolCalls of the previous assistant message
+	ToolResults []ToolResult
+}
+
+// ToolDef describes a function the model may call
+type ToolDef struct {
+	Name        string
+	Description string
+	Parameters  *Schema
+}
+
+// Schema describes the arguments of a function as JSON schema, in the subset every provider understands.
+type Schema struct {
+	// Type is "object", "string", "integer", "boolean" or "array"
+	Type        string             `json:"type"`
+	Description string             `json:"description,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+}
+
+// ToolCall is the model asking for a function to be run
+type ToolCall struct {
+	// ID pairs the call with its result, for providers that need it
+	ID   string
+	Name string
+	Args map[string]interface{}
+}
+
+// ToolResult is the outcome of a ToolCall
+type ToolResult struct {
+	CallID   string
+	Name     string
+	Response map[string]interface{}
+}
+
+// --- HTTP helpers shared by the providers ---
+
+// postJSON sends body as JSON and returns the response, or an error for a non-200 status
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body interface{}) (*http.Response, error) {
+	requestBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request to %s: %w", req.URL.Host, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s returned non-200 status: %s - %s", req.URL.Host, resp.Status, string(bodyBytes))
+	}
+	return resp, nil
+}
+
+// readLines calls handle with every non-empty line of body, until it ends or handle fails.
+// For server-sent events, only the data of "data:" lines is passed on.
+func readLines(body io.Reader, sse bool, handle func(line string) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if sse {
+			data, ok := strings.CutPrefix(line, "data:")
+			if !ok {
+				continue
+			}
+			line = strings.TrimSpace(data)
+		}
+		if line == "" {
+			continue
+		}
+		if err := handle(line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// marshalResponse turns a tool result into the JSON string providers expect as message content
+func marshalResponse(response map[string]interface{}) string {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Sprintf(`{"error": %q}`, err.Error())
+	}
+	return string(data)
+}
@@ -0,0 +1,56 @@
+package llm_utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TaskDraft is a task extracted from a sentence the user typed.
+// Fields the sentence doesn't mention are left empty.
+type TaskDraft struct {
+	Title  string `json:"title"`
+	Status string `json:"status"`
+	// Due is "YYYY-MM-DD" or "YYYY-MM-DD HH:MM" in the user's timezone
+	Due      string   `json:"due"`
+	Priority string   `json:"priority"`
+	Tags     []string `json:"tags"`
+	// Ambiguous is set when the text doesn't clearly describe a single task
+	Ambiguous bool `json:"ambiguous"`
+}
+
+// ParseTask asks the LLM to turn a sentence like "remind me to send the invoice to Budi
+// next Tuesday, high priority" into a TaskDraft. now is the current time in the user's
+// timezone, so relative dates like "next Tuesday" or "besok" can be resolved.
+func (l *LLMService) ParseTask(ctx context.Context, text string, now time.Time) (*TaskDraft, error) {
+	prompt := "You turn a to-do written in plain English or Indonesian into JSON. Reply with ONE JSON object and nothing else, " +
+		"with these fields:\n" +
+		"- \"title\": a short imperative title in the user's language, without the date, priority or filler like \"remind me to\"\n" +
+		"- \"status\": one of \"backlog\", \"in-progress\", \"done\", or \"\" if not mentioned\n" +
+		"- \"due\": the due date as \"YYYY-MM-DD\", or \"YYYY-MM-DD HH:MM\" if a time is given, or \"\" if none\n" +
+		"- \"priority\": one of \"low\", \"normal\", \"high\", \"urgent\", or \"\" if not mentioned\n" +
+		"- \"tags\": a list of short lowercase tags the user asked for explicitly (e.g. with #), or []\n" +
+		"- \"ambiguous\": true if the text is not clearly one task, or the title or date can't be worked out\n\n" +
+		fmt.Sprintf("Now is %s (%s). Resolve relative dates from that.\n\n", now.Format("2006-01-02 15:04"), now.Format("Monday")) +
+		"Text: " + text
+
+	reply, err := l.Provider.Generate(ctx, prompt, WithJSON(), WithTemperature(0))
+	if err != nil {
+		return nil, err
+	}
+
+	// Models sometimes wrap JSON in a code block despite being asked not to
+	reply = strings.TrimSpace(reply)
+	reply = strings.TrimPrefix(reply, "```json")
+	reply = strings.TrimPrefix(reply, "```")
+	reply = strings.TrimSuffix(reply, "```")
+
+	var draft TaskDraft
+	if err := json.Unmarshal([]byte(strings.TrimSpace(reply)), &draft); err != nil {
+		return nil, fmt.Errorf("error decoding task from LLM reply: %w", err)
+	}
+	draft.Title = strings.TrimSpace(draft.Title)
+	return &draft, nil
+}
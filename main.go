@@ -4,18 +4,45 @@ import (
 	"Discord_bot_v1/bot"
 	"Discord_bot_v1/config"
 	"Discord_bot_v1/llm_utils"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
+	// Embed the time zone database, slim containers often ship without one
+	_ "time/tzdata"
 )
 
 func main() {
-	// Load application configurations
-	cfg := config.LoadConfig()
+	// Load application configurations, refusing to start if anything is missing or invalid
+	cfg, err := config.LoadConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 
-	// load llm config
-	MyLLM := llm_utils.LLMService{
-		APIKey: os.Getenv("GEMINI_CREDS"),
+	// Pick the LLM provider, the bot only talks to it through llm_utils.LLMProvider.
+	// Without one the LLM features are turned off.
+	var provider llm_utils.LLMProvider
+	if cfg.LLM.Provider != config.LLMProviderNone {
+		provider, err = llm_utils.NewProvider(llm_utils.ProviderConfig{
+			Name:    cfg.LLM.Provider,
+			APIKey:  cfg.LLM.APIKey,
+			BaseURL: cfg.LLM.BaseURL,
+			Defaults: llm_utils.GenerateOptions{
+				Model:       cfg.LLM.Model,
+				Temperature: cfg.LLM.Temperature,
+				MaxTokens:   cfg.LLM.MaxTokens,
+			},
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
 	}
 
 	// Start the bot
-	bot.Start(cfg.Token, MyLLM)
+	bot.Start(cfg, provider)
 }
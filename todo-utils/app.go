@@ -2,115 +2,249 @@ package todo_utils
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 type TodoApp struct {
 	HttpClient *http.Client
 	APIUrl     string
+
+	// RequestTimeout bounds every single attempt of a request
+	RequestTimeout time.Duration
+	// MaxRetries is how often idempotent requests are retried on 5xx and connection errors
+	MaxRetries int
+	// RetryBaseDelay is the backoff before the first retry, doubled for every later one
+	RetryBaseDelay time.Duration
+	// Breaker fails calls fast while the backend is down. Optional.
+	Breaker *CircuitBreaker
+	// Observer is told about every task created, updated or deleted through this client. Optional.
+	Observer Observer
+}
+
+// Observer gets notified of successful changes made through a TodoApp
+type Observer interface {
+	// TaskSaved is called after a task was created or updated
+	TaskSaved(discordID string, task *Task)
+	// TaskDeleted is called after a task was deleted
+	TaskDeleted(discordID string, taskID string)
+}
+
+// Observers passes every notification on to each of its observers, in order
+type Observers []Observer
+
+// TaskSaved implements Observer
+func (o Observers) TaskSaved(discordID string, task *Task) {
+	for _, observer := range o {
+		observer.TaskSaved(discordID, task)
+	}
+}
+
+// TaskDeleted implements Observer
+func (o Observers) TaskDeleted(discordID string, taskID string) {
+	for _, observer := range o {
+		observer.TaskDeleted(discordID, taskID)
+	}
 }
 
 type CreateTaskRequest struct {
-	Title     string
-	Status    string
-	Discordid string
+	Title       string
+	Status      string
+	Description string   `json:",omitempty"`
+	DueDate     string   `json:",omitempty"`
+	Priority    string   `json:",omitempty"`
+	Tags        []string `json:",omitempty"`
+	Discordid   string
 }
 
 type Task struct {
-	ID        string `json:"id"`
-	Title     string `json:"title"`
-	Status    string `json:"status"`
-	DiscordID string `json:"discord_id"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Status      string `json:"status"`
+	Description string `json:"description"`
+	// DueDate is an RFC 3339 timestamp, empty when the task has no due date
+	DueDate string `json:"due_date"`
+	// Priority is one of Priorities, empty means DefaultPriority
+	Priority string `json:"priority"`
+	// Tags are lowercase labels without the #, e.g. "frontend"
+	Tags      []string `json:"tags"`
+	DiscordID string   `json:"discord_id"`
+	CreatedAt string   `json:"created_at"`
+	UpdatedAt string   `json:"updated_at"`
 }
 
-type TaskListResponse struct {
-	Tasks       []Task `json:"tasks"`
-	Total       int    `json:"total"`
-	Page        int    `json:"page"`
-	Limit       int    `json:"limit"`
-	TotalPages  int    `json:"total_pages"`
+// TaskInput holds the fields of a task a user can set
+type TaskInput struct {
+	Title       string
+	Status      string
+	Description string
+	// DueDate is an RFC 3339 timestamp, empty for no due date
+	DueDate  string
+	Priority string
+	// Tags are added to the #tags found in Title
+	Tags []string
 }
 
-func InitTodoAPP(httpClient *http.Client, API_Url string) *TodoApp {
-	return &TodoApp{
-		HttpClient: httpClient,
-		APIUrl:     API_Url,
+// Priorities are the task priorities, from lowest to highest
+var Priorities = []string{"low", "normal", "high", "urgent"}
+
+// DefaultPriority is the priority of tasks that don't have one
+const DefaultPriority = "normal"
+
+// Input returns the user editable fields of the task
+func (task *Task) Input() TaskInput {
+	return TaskInput{
+		Title:       task.Title,
+		Status:      task.Status,
+		Description: task.Description,
+		DueDate:     task.DueDate,
+		Priority:    task.Priority,
+		Tags:        append([]string(nil), task.Tags...),
+	}
+}
+
+// EffectivePriority returns the task's priority, or DefaultPriority if it has none
+func (task *Task) EffectivePriority() string {
+	if task.Priority == "" {
+		return DefaultPriority
+	}
+	return task.Priority
+}
+
+// PriorityRank orders priorities, higher is more important. Unknown priorities rank as DefaultPriority.
+func PriorityRank(priority string) int {
+	for i, p := range Priorities {
+		if p == priority {
+			return i
+		}
+	}
+	return PriorityRank(DefaultPriority)
+}
+
+// Due parses the due date. ok is false when the task has none or it is malformed.
+func (task *Task) Due() (due time.Time, ok bool) {
+	if task.DueDate == "" {
+		return time.Time{}, false
 	}
+	due, err := time.Parse(time.RFC3339, task.DueDate)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return due, true
 }
 
-// TODO : implement these features
-/*
-	1. Create task
-	2. Delete task
-	3. Update task
-	4. Read a task
-	5. Read all task (paginated by a given number)
-	6. Register for the app
-*/
+// fill copies the input onto the task, used when the backend doesn't echo it back
+func (task *Task) fill(input TaskInput, discordID string) {
+	task.Title, task.Status, task.Description, task.DueDate = input.Title, input.Status, input.Description, input.DueDate
+	task.Priority, task.Tags = input.Priority, input.Tags
+	task.DiscordID = discordID
+}
 
-func (t *TodoApp) CreateTask(title string, status string, userid string) (string, error) {
-	/*
-		1. use the t.httpclient
-		2. call the url with the correct postfix
-		3. handle the response (formatting and such)
-		4. return the formatted message
-	*/
+type TaskListResponse struct {
+	Tasks      []Task `json:"tasks"`
+	Total      int    `json:"total"`
+	Page       int    `json:"page"`
+	Limit      int    `json:"limit"`
+	TotalPages int    `json:"total_pages"`
+}
 
-	// constructing request
-	// for now, hard coded task
-	// TODO: error handling for this block
+// InitTodoAPP creates a client with the default timeout, retry and circuit breaker settings
+func InitTodoAPP(httpClient *http.Client, API_Url string) *TodoApp {
+	return &TodoApp{
+		HttpClient:     httpClient,
+		APIUrl:         API_Url,
+		RequestTimeout: DefaultRequestTimeout,
+		MaxRetries:     DefaultMaxRetries,
+		RetryBaseDelay: DefaultRetryBaseDelay,
+		Breaker:        NewCircuitBreaker(DefaultBreakerThreshold, DefaultBreakerCooldown),
+	}
+}
 
+// CreateTask creates a task and returns it as stored by the backend.
+// An empty description or due date is left out of the request.
+// #tags in the title are moved into the task's tags.
+func (t *TodoApp) CreateTask(ctx context.Context, input TaskInput, userid string) (*Task, error) {
+	input = input.withTitleTags()
 	var requestObj = CreateTaskRequest{
-		Title:     title,
-		Status:    status,
-		Discordid: userid,
+		Title:       input.Title,
+		Status:      input.Status,
+		Description: input.Description,
+		DueDate:     input.DueDate,
+		Priority:    input.Priority,
+		Tags:        input.Tags,
+		Discordid:   userid,
 	}
 
 	// Marshal the struct to JSON
 	jsonData, err := json.Marshal(requestObj)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request object: %w", err)
+		return nil, fmt.Errorf("failed to marshal request object: %w", err)
 	}
 
-	// Send the POST request with application/json header
-	resp, err := t.HttpClient.Post(
-		t.APIUrl+"/task/create", // always include http://
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
+	// Send the POST request. Creating is not idempotent, so it is never retried.
+	statusCode, respBody, err := t.send(ctx, http.MethodPost, t.APIUrl+"/task/create", jsonData, false)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	// Read the response
-	respBody, err := io.ReadAll(resp.Body)
+	// Check the status code and for an "error" field in JSON
+	if err := checkResponse(statusCode, respBody); err != nil {
+		return nil, err
+	}
+
+	task, err := decodeTask(respBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return nil, err
 	}
 
-	// Optional: log the raw body for debugging
-	// fmt.Println(string(respBody))
+	// Fill in what we sent if the backend didn't echo it back
+	if task.Title == "" {
+		task.fill(input, userid)
+	}
 
-	// Check for "error" field in JSON
-	var jsonResp map[string]interface{}
-	if err := json.Unmarshal(respBody, &jsonResp); err == nil {
-		if errVal, exists := jsonResp["error"]; exists {
-			return "", fmt.Errorf("%v", errVal)
-		}
+	if t.Observer != nil {
+		t.Observer.TaskSaved(userid, task)
 	}
+	return task, nil
+}
 
-	return string(respBody), nil
+// TaskQuery narrows down and orders GetTasks. Empty fields are left out of the request.
+// Backends that don't support a parameter ignore it, so callers that need the
+// result filtered or sorted should check with Matches and sort themselves too.
+type TaskQuery struct {
+	Status   string
+	Priority string
+	// Tag keeps tasks carrying this tag, without the #
+	Tag string
+	// Sort is the field to order by, e.g. "due" or "priority"
+	Sort string
+}
 
+// IsZero reports whether the query has no filter and no sort
+func (q TaskQuery) IsZero() bool {
+	return q == TaskQuery{}
 }
 
-func (t *TodoApp) GetTasks(discordID string, page int, limit int) (*TaskListResponse, error) {
+// Matches reports whether a task passes the query's filters
+func (q TaskQuery) Matches(task *Task) bool {
+	if q.Status != "" && task.Status != q.Status {
+		return false
+	}
+	if q.Priority != "" && task.EffectivePriority() != q.Priority {
+		return false
+	}
+	if q.Tag != "" && !HasTag(task.Tags, q.Tag) {
+		return false
+	}
+	return true
+}
+
+// GetTasks fetches one page of the user's tasks, filtered and sorted by query
+func (t *TodoApp) GetTasks(ctx context.Context, discordID string, page int, limit int, query TaskQuery) (*TaskListResponse, error) {
 	// Build URL with query parameters
 	apiURL := fmt.Sprintf("%s/task/user", t.APIUrl)
 
@@ -119,26 +253,31 @@ func (t *TodoApp) GetTasks(discordID string, page int, limit int) (*TaskListResp
 	params.Add("discord_id", discordID)
 	params.Add("page", fmt.Sprintf("%d", page))
 	params.Add("limit", fmt.Sprintf("%d", limit))
+	if query.Status != "" {
+		params.Add("status", query.Status)
+	}
+	if query.Priority != "" {
+		params.Add("priority", query.Priority)
+	}
+	if query.Tag != "" {
+		params.Add("tag", query.Tag)
+	}
+	if query.Sort != "" {
+		params.Add("sort", query.Sort)
+	}
 
 	// Construct full URL
 	fullURL := fmt.Sprintf("%s?%s", apiURL, params.Encode())
 
 	// Make GET request
-	resp, err := t.HttpClient.Get(fullURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Read response
-	body, err := io.ReadAll(resp.Body)
+	statusCode, body, err := t.send(ctx, http.MethodGet, fullURL, nil, true)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, err
 	}
 
 	// Check status code
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	if err := checkResponse(statusCode, body); err != nil {
+		return nil, err
 	}
 
 	// Parse JSON response
@@ -150,100 +289,174 @@ func (t *TodoApp) GetTasks(discordID string, page int, limit int) (*TaskListResp
 	return &taskResponse, nil
 }
 
+// GetTask fetches a single task owned by the given user
+func (t *TodoApp) GetTask(ctx context.Context, taskID string, discordID string) (*Task, error) {
+	// Build URL with query parameters
+	apiURL := fmt.Sprintf("%s/task/%s", t.APIUrl, url.PathEscape(taskID))
+
+	params := url.Values{}
+	params.Add("discord_id", discordID)
+	fullURL := fmt.Sprintf("%s?%s", apiURL, params.Encode())
+
+	// Make GET request
+	statusCode, body, err := t.send(ctx, http.MethodGet, fullURL, nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check status code
+	if err := checkResponse(statusCode, body); err != nil {
+		return nil, err
+	}
+
+	// Parse JSON response
+	task, err := decodeTask(body)
+	if err != nil {
+		return nil, err
+	}
+	if task.ID == "" {
+		task.ID = taskID
+	}
+
+	return task, nil
+}
+
 type UpdateTaskRequest struct {
-	Title     string `json:"Title"`
-	Status    string `json:"Status"`
-	DiscordID string `json:"DiscordID"`
+	Title       string `json:"Title"`
+	Status      string `json:"Status"`
+	Description string `json:"Description"`
+	// DueDate is always sent, an empty one clears the due date
+	DueDate  string `json:"DueDate"`
+	Priority string `json:"Priority"`
+	// Tags is always sent, an empty list removes every tag
+	Tags      []string `json:"Tags"`
+	DiscordID string   `json:"DiscordID"`
 }
 
-func (t *TodoApp) UpdateTask(taskID string, title string, status string, discordID string) (string, error) {
+// UpdateTask replaces every field of a task with the given values.
+// Callers doing a partial update should start from GetTask(...).Input().
+// #tags in the title are added to input.Tags. It returns the updated task.
+func (t *TodoApp) UpdateTask(ctx context.Context, taskID string, input TaskInput, discordID string) (*Task, error) {
 	// Construct the request URL
-	apiURL := fmt.Sprintf("%s/task/edit/%s", t.APIUrl, taskID)
+	apiURL := fmt.Sprintf("%s/task/edit/%s", t.APIUrl, url.PathEscape(taskID))
+
+	input = input.withTitleTags()
 
 	// Create the request object
 	requestObj := UpdateTaskRequest{
-		Title:     title,
-		Status:    status,
-		DiscordID: discordID,
+		Title:       input.Title,
+		Status:      input.Status,
+		Description: input.Description,
+		DueDate:     input.DueDate,
+		Priority:    input.Priority,
+		Tags:        input.Tags,
+		DiscordID:   discordID,
 	}
 
 	// Marshal the struct to JSON
 	jsonData, err := json.Marshal(requestObj)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request object: %w", err)
+		return nil, fmt.Errorf("failed to marshal request object: %w", err)
 	}
 
-	// Create a PUT request
-	req, err := http.NewRequest("PUT", apiURL, bytes.NewBuffer(jsonData))
+	// Send the PUT request. It replaces the whole task, so it is safe to retry.
+	statusCode, respBody, err := t.send(ctx, http.MethodPut, apiURL, jsonData, true)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
-	
-	// Set the content type header
-	req.Header.Set("Content-Type", "application/json")
 
-	// Send the request
-	resp, err := t.HttpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+	// Check the status code and for an "error" field in JSON
+	if err := checkResponse(statusCode, respBody); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	// Read the response
-	respBody, err := io.ReadAll(resp.Body)
+	task, err := decodeTask(respBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return nil, err
 	}
 
-	// Check for "error" field in JSON
-	var jsonResp map[string]interface{}
-	if err := json.Unmarshal(respBody, &jsonResp); err == nil {
-		if errVal, exists := jsonResp["error"]; exists {
-			return "", fmt.Errorf("%v", errVal)
-		}
+	// Fill in what we sent if the backend didn't echo it back
+	if task.ID == "" {
+		task.ID = taskID
+	}
+	if task.Title == "" {
+		task.fill(input, discordID)
+	}
+
+	if t.Observer != nil {
+		t.Observer.TaskSaved(discordID, task)
 	}
+	return task, nil
+}
 
-	return string(respBody), nil
+// DeleteResult is what DeleteTask returns on success
+type DeleteResult struct {
+	// ID of the deleted task
+	ID string `json:"id"`
+	// Message is the confirmation from the backend, if it sent one
+	Message string `json:"message"`
 }
 
-func (t *TodoApp) DeleteTask(taskID string, discordID string) (string, error) {
+// DeleteTask deletes a task owned by the given user
+func (t *TodoApp) DeleteTask(ctx context.Context, taskID string, discordID string) (*DeleteResult, error) {
 	// Construct the request URL with query parameters
-	apiURL := fmt.Sprintf("%s/task/delete/%s", t.APIUrl, taskID)
-	
+	apiURL := fmt.Sprintf("%s/task/delete/%s", t.APIUrl, url.PathEscape(taskID))
+
 	// Add discord_id as a query parameter
 	params := url.Values{}
 	params.Add("discord_id", discordID)
 	fullURL := fmt.Sprintf("%s?%s", apiURL, params.Encode())
 
-	// Create a DELETE request
-	req, err := http.NewRequest("DELETE", fullURL, nil)
+	// Send the DELETE request
+	statusCode, respBody, err := t.send(ctx, http.MethodDelete, fullURL, nil, true)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
-	// Set the content type header
-	req.Header.Set("Content-Type", "application/json")
+	// Check the status code and for an "error" field in JSON
+	if err := checkResponse(statusCode, respBody); err != nil {
+		return nil, err
+	}
 
-	// Send the request
-	resp, err := t.HttpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+	// The body is optional, a bare 204 is fine too
+	result := &DeleteResult{}
+	if len(bytes.TrimSpace(respBody)) > 0 {
+		if err := json.Unmarshal(respBody, result); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+	if result.ID == "" {
+		result.ID = taskID
 	}
-	defer resp.Body.Close()
 
-	// Read the response
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+	if t.Observer != nil {
+		t.Observer.TaskDeleted(discordID, taskID)
 	}
+	return result, nil
+}
 
-	// Check for "error" field in JSON
-	var jsonResp map[string]interface{}
-	if err := json.Unmarshal(respBody, &jsonResp); err == nil {
-		if errVal, exists := jsonResp["error"]; exists {
-			return "", fmt.Errorf("%v", errVal)
-		}
+// taskEnvelope covers the backend wrapping a task as {"task": {...}} or {"data": {...}}
+type taskEnvelope struct {
+	Task *Task `json:"task"`
+	Data *Task `json:"data"`
+}
+
+// decodeTask parses a single task from a response body, either bare or wrapped
+func decodeTask(body []byte) (*Task, error) {
+	var envelope taskEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if envelope.Task != nil {
+		return envelope.Task, nil
+	}
+	if envelope.Data != nil {
+		return envelope.Data, nil
 	}
 
-	return string(respBody), nil
+	var task Task
+	if err := json.Unmarshal(body, &task); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &task, nil
 }
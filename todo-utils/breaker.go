@@ -0,0 +1,78 @@
+package todo_utils
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned while the circuit breaker is failing fast.
+// It wraps ErrUnavailable, so errors.Is(err, ErrUnavailable) still holds.
+var ErrCircuitOpen = fmt.Errorf("%w: circuit breaker open", ErrUnavailable)
+
+// CircuitBreaker stops calls to the backend after too many consecutive failures.
+// Once Cooldown has passed, a single trial call is let through: if it succeeds the
+// breaker closes again, otherwise it stays open for another Cooldown.
+type CircuitBreaker struct {
+	// FailureThreshold is the number of consecutive failures that opens the breaker
+	FailureThreshold int
+	// Cooldown is how long the breaker stays open before letting a trial call through
+	Cooldown time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	trial     bool
+}
+
+// NewCircuitBreaker creates a closed breaker
+func NewCircuitBreaker(failureThreshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		FailureThreshold: failureThreshold,
+		Cooldown:         cooldown,
+	}
+}
+
+// Allow reports whether a call may go through, returning ErrCircuitOpen if not
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.FailureThreshold {
+		return nil
+	}
+	if time.Now().Before(b.openUntil) || b.trial {
+		return ErrCircuitOpen
+	}
+
+	// Cooldown is over, let one trial call through
+	b.trial = true
+	return nil
+}
+
+// Success records a successful call and closes the breaker
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.trial = false
+}
+
+// Failure records a failed call, opening the breaker once the threshold is reached
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.trial = false
+	if b.failures >= b.FailureThreshold {
+		b.openUntil = time.Now().Add(b.Cooldown)
+	}
+}
+
+// Abandon records a call the caller gave up on before it finished. It counts neither
+// as a success nor as a failure, but lets the next trial call through.
+func (b *CircuitBreaker) Abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
@@ -0,0 +1,103 @@
+package todo_utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Sentinel errors returned (wrapped) by TodoApp methods. Use errors.Is to check for them.
+var (
+	ErrNotFound    = errors.New("task not found")
+	ErrForbidden   = errors.New("not allowed to access this task")
+	ErrValidation  = errors.New("invalid request")
+	ErrUnavailable = errors.New("todo service unavailable")
+)
+
+// APIError is returned when the todo backend answers with an error
+type APIError struct {
+	StatusCode int
+	// Code is the machine readable error code from the backend, if it sent one
+	Code string
+	// Message is the human readable error from the backend
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("todo API error %d (%s): %s", e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("todo API error %d: %s", e.StatusCode, e.Message)
+}
+
+// Is maps the status code to one of the sentinel errors, so
+// errors.Is(err, ErrNotFound) works on an *APIError
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrForbidden:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrValidation:
+		return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity
+	case ErrUnavailable:
+		return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// errorBody covers the error shapes the backend sends:
+// {"error": "message"}, {"error": {"code": "...", "message": "..."}} and {"code": "...", "message": "..."}
+type errorBody struct {
+	Error   json.RawMessage `json:"error"`
+	Code    string          `json:"code"`
+	Message string          `json:"message"`
+}
+
+// checkResponse returns an *APIError if the status code is not 2xx or the body
+// carries an "error" field, and nil otherwise
+func checkResponse(statusCode int, body []byte) error {
+	var parsed errorBody
+	jsonErr := json.Unmarshal(body, &parsed)
+	hasErrorField := jsonErr == nil && len(parsed.Error) > 0 && string(parsed.Error) != "null"
+
+	if statusCode >= 200 && statusCode < 300 && !hasErrorField {
+		return nil
+	}
+
+	apiErr := &APIError{StatusCode: statusCode, Code: parsed.Code, Message: parsed.Message}
+
+	if hasErrorField {
+		var message string
+		var nested struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(parsed.Error, &message); err == nil {
+			apiErr.Message = message
+		} else if err := json.Unmarshal(parsed.Error, &nested); err == nil {
+			apiErr.Code, apiErr.Message = nested.Code, nested.Message
+		}
+	}
+
+	// Some endpoints report errors with a 200, treat those as validation errors
+	if apiErr.StatusCode >= 200 && apiErr.StatusCode < 300 {
+		apiErr.StatusCode = http.StatusBadRequest
+	}
+
+	// Fall back to the raw body (or status text) so the error is never empty
+	if apiErr.Message == "" {
+		apiErr.Message = string(body)
+		if jsonErr != nil || apiErr.Message == "" {
+			apiErr.Message = http.StatusText(statusCode)
+		}
+	}
+
+	return apiErr
+}
+
+// requestError wraps a failure to reach the backend at all
+func requestError(err error) error {
+	return fmt.Errorf("failed to send request: %w: %w", ErrUnavailable, err)
+}
@@ -0,0 +1,74 @@
+package todo_utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maxTagLength is the longest tag kept, longer #tokens are left in the title
+const maxTagLength = 32
+
+// ParseTags pulls the #tag tokens out of a title. It returns the title without
+// them and the tags, lowercased, without the # and without duplicates.
+// A title made of nothing but tags is returned unchanged, so it never ends up empty.
+func ParseTags(title string) (string, []string) {
+	words := []string{}
+	tags := []string{}
+	for _, word := range strings.Fields(title) {
+		tag, ok := NormalizeTag(word)
+		if !ok || !strings.HasPrefix(word, "#") {
+			words = append(words, word)
+			continue
+		}
+		tags = MergeTags(tags, []string{tag})
+	}
+
+	if len(words) == 0 {
+		return title, tags
+	}
+	return strings.Join(words, " "), tags
+}
+
+// NormalizeTag turns "#Frontend" or "frontend" into "frontend".
+// ok is false when the rest isn't a valid tag: letters, digits, - and _ only.
+func NormalizeTag(raw string) (tag string, ok bool) {
+	tag = strings.ToLower(strings.TrimPrefix(raw, "#"))
+	if tag == "" || len(tag) > maxTagLength {
+		return "", false
+	}
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return "", false
+		}
+	}
+	return tag, true
+}
+
+// MergeTags appends the tags in extra that aren't in tags yet, keeping the order
+func MergeTags(tags []string, extra []string) []string {
+	merged := append([]string{}, tags...)
+	for _, tag := range extra {
+		if !HasTag(merged, tag) {
+			merged = append(merged, tag)
+		}
+	}
+	return merged
+}
+
+// HasTag reports whether tags contains tag
+func HasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// withTitleTags moves the #tags in the title into the input's tag list
+func (input TaskInput) withTitleTags() TaskInput {
+	title, tags := ParseTags(input.Title)
+	input.Title = title
+	input.Tags = MergeTags(input.Tags, tags)
+	return input
+}
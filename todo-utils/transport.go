@@ -0,0 +1,149 @@
+package todo_utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// Defaults used by InitTodoAPP
+const (
+	DefaultRequestTimeout   = 5 * time.Second
+	DefaultMaxRetries       = 2
+	DefaultRetryBaseDelay   = 200 * time.Millisecond
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
+// maxRetryDelay caps the backoff between two attempts
+const maxRetryDelay = 2 * time.Second
+
+// send performs a request against the backend and returns the status code and body.
+//   - every attempt gets its own RequestTimeout, on top of whatever deadline ctx has
+//   - idempotent requests are retried up to MaxRetries times on 5xx and connection
+//     errors, with exponential backoff and jitter
+//   - while the circuit breaker is open, it fails fast with ErrCircuitOpen
+//   - a 404 on a retried DELETE counts as deleted
+func (t *TodoApp) send(ctx context.Context, method string, url string, body []byte, idempotent bool) (int, []byte, error) {
+	attempts := 1
+	if idempotent {
+		attempts += t.MaxRetries
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, t.retryDelay(attempt)); err != nil {
+				return 0, nil, requestError(err)
+			}
+		}
+
+		if t.Breaker != nil {
+			if err := t.Breaker.Allow(); err != nil {
+				return 0, nil, err
+			}
+		}
+
+		statusCode, respBody, err := t.attempt(ctx, method, url, body)
+
+		// Connection errors and 5xx count against the breaker and are worth a retry.
+		// When the caller's own deadline ran out or it cancelled, that says nothing
+		// about the backend, so the breaker isn't told.
+		if err != nil || statusCode >= 500 {
+			if t.Breaker != nil {
+				if ctx.Err() != nil {
+					t.Breaker.Abandon()
+				} else {
+					t.Breaker.Failure()
+				}
+			}
+			if err != nil {
+				lastErr = requestError(err)
+			} else {
+				lastErr = checkResponse(statusCode, respBody)
+			}
+
+			// Don't retry once the caller has given up
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+
+		if t.Breaker != nil {
+			t.Breaker.Success()
+		}
+
+		// A retried DELETE whose earlier attempt went through, but whose response was
+		// lost, finds the task gone. That is what the caller asked for.
+		if method == http.MethodDelete && attempt > 0 && statusCode == http.StatusNotFound {
+			return http.StatusNoContent, nil, nil
+		}
+		return statusCode, respBody, nil
+	}
+
+	return 0, nil, lastErr
+}
+
+// attempt sends a single request with its own timeout
+func (t *TodoApp) attempt(ctx context.Context, method string, url string, body []byte) (int, []byte, error) {
+	if t.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.RequestTimeout)
+		defer cancel()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := t.HttpClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return resp.StatusCode, respBody, nil
+}
+
+// retryDelay returns the backoff before the given attempt: the base delay doubled
+// per attempt, capped at maxRetryDelay, with up to 50% random jitter
+func (t *TodoApp) retryDelay(attempt int) time.Duration {
+	delay := t.RetryBaseDelay << (attempt - 1)
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	jitter := time.Duration(rand.Int63n(int64(delay)/2 + 1))
+	return delay/2 + jitter
+}
+
+// sleepContext waits for d, or returns early if ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}